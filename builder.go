@@ -0,0 +1,147 @@
+package glob
+
+import (
+	"fmt"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// Builder constructs a Glob programmatically, one segment at a time, taking
+// care of all escaping.  The zero value is an empty pattern ready for use.
+//
+//	g := new(glob.Builder).
+//		Literal(userDir).
+//		Literal("/").
+//		DoubleStarSlash().
+//		Star().
+//		Literal(".go").
+//		MustBuild()
+type Builder struct {
+	pattern []rune
+	last    SegmentType
+	hasLast bool
+	err     error
+}
+
+// Literal appends a segment which matches s exactly.
+func (b *Builder) Literal(s string) *Builder {
+	if s == "" {
+		return b
+	}
+	for index := 0; index < len(s); {
+		ch, size := guts.DecodeRawRune(s[index:])
+		if index == 0 && ch == '/' && b.lastIs(DoubleStarSegment) {
+			// "**/" would be read back as a DoubleStarSlashSegment,
+			// so spell the slash as a set instead.
+			b.pattern = append(b.pattern, '[', '/', ']')
+		} else {
+			b.pattern = guts.SafeAppendRune(b.pattern, ch)
+		}
+		index += size
+	}
+	return b.emit(LiteralSegment)
+}
+
+// Question appends a segment which matches any one rune other than '/'.
+func (b *Builder) Question() *Builder {
+	b.pattern = append(b.pattern, '?')
+	return b.emit(QuestionSegment)
+}
+
+// Star appends a segment which matches zero or more runes other than '/'.
+func (b *Builder) Star() *Builder {
+	if b.lastIs(StarSegment) || b.lastIs(DoubleStarSegment) {
+		// The previous segment already matches everything this one
+		// could; adding it would not change the meaning.
+		return b
+	}
+	b.pattern = append(b.pattern, '*')
+	return b.emit(StarSegment)
+}
+
+// DoubleStar appends a segment which matches zero or more runes of any kind.
+func (b *Builder) DoubleStar() *Builder {
+	if b.lastIs(DoubleStarSegment) {
+		return b
+	}
+	if b.lastIs(DoubleStarSlashSegment) {
+		// "**/" followed by "**" is equivalent to "**" alone.
+		b.pattern = b.pattern[:len(b.pattern)-1]
+		b.last = DoubleStarSegment
+		return b
+	}
+	if b.lastIs(StarSegment) {
+		// "*" followed by "**" is equivalent to "**" alone.
+		b.pattern = append(b.pattern, '*')
+		b.last = DoubleStarSegment
+		return b
+	}
+	b.pattern = append(b.pattern, '*', '*')
+	return b.emit(DoubleStarSegment)
+}
+
+// DoubleStarSlash appends a segment which matches zero or more whole
+// directories, i.e. either the empty string or any string ending in '/'.
+func (b *Builder) DoubleStarSlash() *Builder {
+	if b.lastIs(DoubleStarSegment) || b.lastIs(DoubleStarSlashSegment) {
+		// "**" or "**/" followed by "**/" is equivalent to the former
+		// alone.
+		return b
+	}
+	if b.lastIs(StarSegment) {
+		b.fail("DoubleStarSlash cannot directly follow Star")
+		return b
+	}
+	b.pattern = append(b.pattern, '*', '*', '/')
+	return b.emit(DoubleStarSlashSegment)
+}
+
+// Class appends a segment which matches any one rune in the set m.
+func (b *Builder) Class(m RuneMatcher) *Builder {
+	b.pattern = guts.AppendRuneMatcher(b.pattern, ClassOf(m).matcher())
+	return b.emit(RuneMatchSegment)
+}
+
+// Pattern returns the pattern text built so far.
+func (b *Builder) Pattern() string {
+	return string(b.pattern)
+}
+
+func (b *Builder) String() string {
+	return b.Pattern()
+}
+
+// Build compiles the pattern built so far.
+func (b *Builder) Build(opts ...Option) (*Glob, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return Compile(b.Pattern(), opts...)
+}
+
+// MustBuild is like Build, but panics on error.
+func (b *Builder) MustBuild(opts ...Option) *Glob {
+	compiled, err := b.Build(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+func (b *Builder) lastIs(t SegmentType) bool {
+	return b.hasLast && b.last == t
+}
+
+func (b *Builder) emit(t SegmentType) *Builder {
+	b.last = t
+	b.hasLast = true
+	return b
+}
+
+func (b *Builder) fail(format string, args ...interface{}) {
+	if b.err == nil {
+		b.err = fmt.Errorf("glob: Builder: "+format, args...)
+	}
+}
+
+var _ fmt.Stringer = (*Builder)(nil)
@@ -0,0 +1,96 @@
+package glob
+
+import (
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// Canonical returns a pattern with the same meaning as g, spelled in a
+// standard way: adjacent literals are merged, single-rune sets such as "[a]"
+// become literals (except a "[.]" which hides dotfiles), "[^/]" becomes
+// "?", other sets are sorted and deduplicated, redundant "**" components
+// are collapsed, and only the escapes which are needed are kept.  Two
+// patterns with the same Canonical form are Equivalent, although the
+// reverse is not always true.
+//
+// For a Glob built by Or, And or Not, Canonical returns the same expression
+// as String, with each operand in canonical form.
+func (g *Glob) Canonical() string {
+	if g.op != leafOp {
+		return g.canonicalCombined()
+	}
+
+	var b Builder
+	var literal []rune
+	flush := func() {
+		if len(literal) > 0 {
+			b.Literal(guts.EncodeRawRunes(literal))
+			literal = literal[:0]
+		}
+	}
+
+	notSlash := guts.Is('/').Not()
+	for i := range g.impl.Segments {
+		seg := &g.impl.Segments[i]
+		switch seg.Type {
+		case guts.LiteralSegment:
+			literal = append(literal, seg.Literal.Runes...)
+			continue
+		case guts.RuneMatchSegment:
+			ranges := guts.Ranges(seg.Matcher)
+			if len(ranges) == 1 && ranges[0].Lo == ranges[0].Hi {
+				// With hidden dotfiles, "[.]" cannot match a '.' at
+				// the start of a component, but "." can.
+				hidden := ranges[0].Lo == '.' && g.impl.Options.HideDotfiles && g.impl.StartsComponent(uint(i))
+				if !hidden {
+					literal = append(literal, ranges[0].Lo)
+					continue
+				}
+			}
+		}
+
+		flush()
+		switch seg.Type {
+		case guts.RuneMatchSegment:
+			if guts.EqualMatchers(seg.Matcher, notSlash) {
+				b.Question()
+			} else {
+				b.Class(seg.Matcher)
+			}
+		case guts.QuestionSegment:
+			b.Question()
+		case guts.StarSegment:
+			b.Star()
+		case guts.DoubleStarSegment:
+			b.DoubleStar()
+		case guts.DoubleStarSlashSegment:
+			b.DoubleStarSlash()
+		}
+	}
+	flush()
+	return b.Pattern()
+}
+
+func (g *Glob) canonicalCombined() string {
+	return g.canonicalTree().combinedString(false)
+}
+
+// canonicalTree returns a copy of g with every leaf recompiled from its
+// canonical form.
+func (g *Glob) canonicalTree() *Glob {
+	if g.op == leafOp {
+		return MustCompile(g.Canonical(), withOptions(g.impl.Options))
+	}
+	out := &Glob{op: g.op, operands: make([]*Glob, len(g.operands)), prefix: g.prefix}
+	for i, operand := range g.operands {
+		out.operands[i] = operand.canonicalTree()
+	}
+	return out
+}
+
+// Equivalent returns true iff a and b match exactly the same strings.
+func Equivalent(a, b *Glob) bool {
+	_, found := search([]*Glob{a, b}, func(in []bool) bool {
+		return in[0] != in[1]
+	})
+	return !found
+}
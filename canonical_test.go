@@ -0,0 +1,94 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestGlob_Canonical(t *testing.T) {
+	type testRow struct {
+		Input    string
+		Expected string
+	}
+
+	testData := [...]testRow{
+		{"", ""},
+		{"abc", "abc"},
+		{"a[b]c", "abc"},
+		{"[a][b][c]", "abc"},
+		{`\x61\u0062c`, "abc"},
+		{`a\*b`, `a\*b`},
+		{"[*]", `\*`},
+		{"[cba]", "[a-c]"},
+		{"[aabbc]", "[a-c]"},
+		{"[z-za-c]", "[a-cz]"},
+		{"[^/]", "?"},
+		{"**/**/", "**/"},
+		{"**/**/**/a", "**/a"},
+		{"a/**/**", "a/**"},
+		{"**[/]x", "**[/]x"},
+		{"src/**/[0-9]?*.go", "src/**/[0-9]?*.go"},
+	}
+
+	for _, row := range testData {
+		t.Run(row.Input, func(t *testing.T) {
+			g := MustCompile(row.Input)
+			actual := g.Canonical()
+			if actual != row.Expected {
+				t.Errorf("expected %q, got %q", row.Expected, actual)
+			}
+			c, err := Compile(actual)
+			if err != nil {
+				t.Fatalf("Compile(%q): unexpected error: %v", actual, err)
+			}
+			if !Equivalent(g, c) {
+				t.Errorf("%q is not equivalent to %q", actual, row.Input)
+			}
+			if again := c.Canonical(); again != actual {
+				t.Errorf("not idempotent: %q -> %q", actual, again)
+			}
+		})
+	}
+
+	// With hidden dotfiles, "[.]x" never matches ".x", so it must not
+	// become ".x".
+	for _, pattern := range []string{"[.]x", "a/[.]x"} {
+		g := MustCompile(pattern, WithDialect(Bash))
+		if actual := g.Canonical(); actual != pattern {
+			t.Errorf("HideDotfiles: expected %q, got %q", pattern, actual)
+		}
+	}
+	if actual := MustCompile("a[.]x", WithDialect(Bash)).Canonical(); actual != "a.x" {
+		t.Errorf("HideDotfiles: expected %q, got %q", "a.x", actual)
+	}
+
+	g := Or(MustCompile("src/[a]*"), Not(MustCompile("[x]")))
+	if actual := g.Canonical(); actual != `Or("src/a*", Not("x"))` {
+		t.Errorf("combined: unexpected %q", actual)
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	type testRow struct {
+		A        string
+		B        string
+		Expected bool
+	}
+
+	testData := [...]testRow{
+		{"a[b]c", "abc", true},
+		{"**/**/a", "**/a", true},
+		{"[^/]", "?", true},
+		{"*", "**", false},
+		{"a*", "a*b", false},
+		{"**", "**/**", true},
+		{"[a-c]", "[abc]", true},
+	}
+
+	for _, row := range testData {
+		a := MustCompile(row.A)
+		b := MustCompile(row.B)
+		if actual := Equivalent(a, b); actual != row.Expected {
+			t.Errorf("Equivalent(%q, %q): expected %v, got %v", row.A, row.B, row.Expected, actual)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package glob
+
+import (
+	"fmt"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// Class is an immutable set of runes, with the same semantics as a character
+// set such as "[a-z]" within a glob pattern.
+type Class struct {
+	impl guts.RuneMatcher
+}
+
+// RuneRange is an inclusive range of runes.
+type RuneRange struct {
+	Lo rune
+	Hi rune
+}
+
+func newClass(impl guts.RuneMatcher) *Class {
+	return &Class{impl: impl}
+}
+
+// CompileClass parses a single character set in glob syntax, e.g. "[^0-9]".
+// A single literal rune, e.g. "x", is also accepted.
+func CompileClass(input string) (*Class, error) {
+	g, err := Compile(input)
+	if err != nil {
+		return nil, err
+	}
+	segments := g.impl.Segments
+	if len(segments) == 1 {
+		seg := segments[0]
+		switch {
+		case seg.Type == guts.RuneMatchSegment:
+			return newClass(seg.Matcher), nil
+		case seg.Type == guts.LiteralSegment && len(seg.Literal.Runes) == 1:
+			return newClass(guts.Is(seg.Literal.Runes[0])), nil
+		}
+	}
+	return nil, fmt.Errorf("failed to parse character class: %q: not a single character set", input)
+}
+
+// MustCompileClass is like CompileClass, but panics on error.
+func MustCompileClass(input string) *Class {
+	compiled, err := CompileClass(input)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+// AnyClass returns the set of all runes.
+func AnyClass() *Class {
+	return newClass(guts.Any())
+}
+
+// NoneClass returns the empty set.
+func NoneClass() *Class {
+	return newClass(guts.None())
+}
+
+// RuneClass returns the set containing only ch.
+func RuneClass(ch rune) *Class {
+	return newClass(guts.Is(ch))
+}
+
+// RangeClass returns the set of runes from lo to hi, inclusive.  It panics if
+// lo > hi.
+func RangeClass(lo, hi rune) *Class {
+	return newClass(guts.Range(lo, hi))
+}
+
+// ClassOf returns the set of runes matched by m.
+func ClassOf(m RuneMatcher) *Class {
+	if c, ok := m.(*Class); ok {
+		return c
+	}
+	ranges := make([]guts.LoHi, 0, 8)
+	m.ForEachRange(func(lo, hi rune) {
+		ranges = append(ranges, guts.LoHi{Lo: lo, Hi: hi})
+	})
+	return newClass(guts.BuildSet(ranges))
+}
+
+func (c *Class) matcher() guts.RuneMatcher {
+	if c == nil || c.impl == nil {
+		return guts.None()
+	}
+	return c.impl
+}
+
+// Union returns the set of runes in c or in any of others.
+func (c *Class) Union(others ...*Class) *Class {
+	matchers := make([]guts.RuneMatcher, 0, 1+len(others))
+	matchers = append(matchers, c.matcher())
+	for _, other := range others {
+		matchers = append(matchers, other.matcher())
+	}
+	return newClass(guts.Union(matchers...))
+}
+
+// Intersect returns the set of runes in both c and other.
+func (c *Class) Intersect(other *Class) *Class {
+	return newClass(guts.Intersect(c.matcher(), other.matcher()))
+}
+
+// Subtract returns the set of runes in c but not in other.
+func (c *Class) Subtract(other *Class) *Class {
+	return newClass(guts.Subtract(c.matcher(), other.matcher()))
+}
+
+// Not returns the set of runes not in c.
+func (c *Class) Not() *Class {
+	return newClass(c.matcher().Not())
+}
+
+// Contains returns true iff ch is in c.
+func (c *Class) Contains(ch rune) bool {
+	return c.matcher().MatchRune(ch)
+}
+
+// IsEmpty returns true iff c contains no runes.
+func (c *Class) IsEmpty() bool {
+	empty := true
+	c.matcher().ForEachRange(func(lo, hi rune) {
+		empty = false
+	})
+	return empty
+}
+
+// Equal returns true iff c and other contain exactly the same runes.
+func (c *Class) Equal(other *Class) bool {
+	return guts.EqualMatchers(c.matcher(), other.matcher())
+}
+
+// Ranges returns the runes in c as a sorted list of non-adjacent ranges.
+func (c *Class) Ranges() []RuneRange {
+	ranges := guts.Ranges(c.matcher())
+	out := make([]RuneRange, len(ranges))
+	for i, r := range ranges {
+		out[i] = RuneRange{Lo: r.Lo, Hi: r.Hi}
+	}
+	return out
+}
+
+func (c *Class) MatchRune(ch rune) bool {
+	return c.Contains(ch)
+}
+
+func (c *Class) ForEachRange(fn func(lo, hi rune)) {
+	c.matcher().ForEachRange(fn)
+}
+
+// String returns c in canonical glob syntax, e.g. "[0-9A-Za-z]".
+func (c *Class) String() string {
+	return string(guts.AppendRuneMatcher(nil, c.matcher()))
+}
+
+func (c *Class) GoString() string {
+	return fmt.Sprintf("glob.MustCompileClass(%q)", c.String())
+}
+
+var _ RuneMatcher = (*Class)(nil)
+var _ fmt.Stringer = (*Class)(nil)
+var _ fmt.GoStringer = (*Class)(nil)
@@ -0,0 +1,110 @@
+package glob
+
+import (
+	"testing"
+	"unicode"
+)
+
+func TestClass(t *testing.T) {
+	type testrow struct {
+		Name         string
+		C            *Class
+		ExpectString string
+		ExpectRanges []RuneRange
+		ExpectAccept []rune
+		ExpectReject []rune
+	}
+
+	lower := RangeClass('a', 'z')
+	upper := RangeClass('A', 'Z')
+	vowels := MustCompileClass("[aeiou]")
+
+	testdata := []testrow{
+		{
+			Name:         "None",
+			C:            NoneClass(),
+			ExpectString: "[]",
+			ExpectRanges: []RuneRange{},
+			ExpectReject: []rune{0, 'a', unicode.MaxRune},
+		},
+		{
+			Name:         "Any",
+			C:            AnyClass(),
+			ExpectString: "[^]",
+			ExpectRanges: []RuneRange{{0, unicode.MaxRune}},
+			ExpectAccept: []rune{0, 'a', unicode.MaxRune},
+		},
+		{
+			Name:         "Union",
+			C:            lower.Union(upper, RuneClass('_')),
+			ExpectString: "[A-Z_a-z]",
+			ExpectRanges: []RuneRange{{'A', 'Z'}, {'_', '_'}, {'a', 'z'}},
+			ExpectAccept: []rune{'A', '_', 'q'},
+			ExpectReject: []rune{'0', '-', 'é'},
+		},
+		{
+			Name:         "Intersect",
+			C:            lower.Intersect(MustCompileClass("[x-~]")),
+			ExpectString: "[x-z]",
+			ExpectRanges: []RuneRange{{'x', 'z'}},
+			ExpectAccept: []rune{'x', 'z'},
+			ExpectReject: []rune{'w', '{'},
+		},
+		{
+			Name:         "Subtract",
+			C:            lower.Subtract(vowels),
+			ExpectString: "[b-df-hj-np-tv-z]",
+			ExpectAccept: []rune{'b', 'z'},
+			ExpectReject: []rune{'a', 'e', 'u', 'B'},
+			ExpectRanges: []RuneRange{{'b', 'd'}, {'f', 'h'}, {'j', 'n'}, {'p', 't'}, {'v', 'z'}},
+		},
+		{
+			Name:         "Not",
+			C:            MustCompileClass("[^\\-\\]]"),
+			ExpectString: "[^\\-\\]]",
+			ExpectRanges: []RuneRange{{0, ','}, {'.', '\\'}, {'^', unicode.MaxRune}},
+			ExpectAccept: []rune{'a', '['},
+			ExpectReject: []rune{'-', ']'},
+		},
+	}
+
+	for _, row := range testdata {
+		t.Run(row.Name, func(t *testing.T) {
+			if actual := row.C.String(); actual != row.ExpectString {
+				t.Errorf("String: expected %q, got %q", row.ExpectString, actual)
+			}
+			if actual := row.C.Ranges(); !equalRuneRanges(actual, row.ExpectRanges) {
+				t.Errorf("Ranges: expected %v, got %v", row.ExpectRanges, actual)
+			}
+			if reparsed := MustCompileClass(row.C.String()); !reparsed.Equal(row.C) {
+				t.Errorf("String: %q does not round-trip, got %q", row.C.String(), reparsed.String())
+			}
+			for _, ch := range row.ExpectAccept {
+				if !row.C.Contains(ch) {
+					t.Errorf("Contains %q: expected true, got false", ch)
+				}
+			}
+			for _, ch := range row.ExpectReject {
+				if row.C.Contains(ch) {
+					t.Errorf("Contains %q: expected false, got true", ch)
+				}
+			}
+		})
+	}
+
+	if _, err := CompileClass("ab"); err == nil {
+		t.Errorf("CompileClass %q: expected error", "ab")
+	}
+}
+
+func equalRuneRanges(a, b []RuneRange) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,322 @@
+// Package codeowners parses CODEOWNERS files, as used by GitHub and GitLab,
+// and resolves the owners of a path.
+//
+// Patterns follow gitignore conventions: a pattern which contains a '/'
+// other than at its end is anchored to the root of the repository, and any
+// other pattern matches at any depth.  A pattern matches both the path it
+// names and everything beneath it, unless it ends in '/', in which case it
+// matches only the contents of a directory.  As on GitHub, a pattern whose
+// last component is "*", such as "docs/*", matches only the direct children
+// of a directory and not further nested paths.  The syntax is that of
+// gitignore too: braces are literal, "**" matches across '/' only as a whole
+// path component, and "[!...]" is a negated set.
+//
+// GitLab sections are supported:
+//
+//	[Documentation] @docs-team
+//	docs/
+//	^[Optional section][2] @reviewers
+//	*.md @writers
+//
+// Within each section the last matching entry wins, and the owners from every
+// section which matches are combined.  Entries which appear before the first
+// section header belong to an unnamed section, so a file without sections
+// behaves as GitHub's does.
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	glob "github.com/team-spectre/go-glob"
+)
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	Sections []*Section
+}
+
+// Section is a GitLab section, or the unnamed section which holds entries
+// before the first section header.  Line is 0 for the unnamed section.
+type Section struct {
+	Name          string
+	Optional      bool
+	Approvals     uint
+	DefaultOwners []string
+	Line          uint
+	Entries       []*Entry
+}
+
+// Entry is one pattern line.  Pattern is the pattern as written, and Owners
+// lists the owners as written; if none were given, the entry inherits the
+// default owners of its section.  An entry without owners in a section
+// without default owners makes the matching paths unowned.
+type Entry struct {
+	Pattern string
+	Owners  []string
+	Line    uint
+	Section *Section
+	glob    *glob.Glob
+}
+
+// Match records the entry which decided the owners of a path within one
+// section.
+type Match struct {
+	Entry  *Entry
+	Owners []string
+}
+
+// ParseError is returned by Parse for a malformed line.
+type ParseError struct {
+	Line    uint
+	Text    string
+	Message string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("codeowners: line %d: %q: %s", err.Line, err.Text, err.Message)
+}
+
+// Parse reads a CODEOWNERS file.
+func Parse(r io.Reader) (*File, error) {
+	f := &File{}
+	current := &Section{}
+	f.Sections = append(f.Sections, current)
+	byName := make(map[string]*Section)
+
+	scanner := bufio.NewScanner(r)
+	var lineno uint
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fail := func(format string, args ...interface{}) error {
+			return &ParseError{Line: lineno, Text: line, Message: fmt.Sprintf(format, args...)}
+		}
+
+		if strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[") {
+			section, err := parseSection(line, lineno)
+			if err != nil {
+				return nil, fail("%s", err.Error())
+			}
+			// GitLab merges sections with the same name, ignoring case.
+			key := strings.ToLower(section.Name)
+			if existing, found := byName[key]; found {
+				current = existing
+				continue
+			}
+			byName[key] = section
+			f.Sections = append(f.Sections, section)
+			current = section
+			continue
+		}
+
+		fields := splitFields(line)
+		pattern := fields[0]
+		g, err := compilePattern(pattern)
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		current.Entries = append(current.Entries, &Entry{
+			Pattern: pattern,
+			Owners:  fields[1:],
+			Line:    lineno,
+			Section: current,
+			glob:    g,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ParseString is like Parse, but reads from a string.
+func ParseString(text string) (*File, error) {
+	return Parse(strings.NewReader(text))
+}
+
+// parseSection parses a header such as "^[Name][2] @owner".
+func parseSection(line string, lineno uint) (*Section, error) {
+	section := &Section{Line: lineno}
+	if strings.HasPrefix(line, "^") {
+		section.Optional = true
+		line = line[1:]
+	}
+
+	end := strings.IndexByte(line, ']')
+	if end < 0 {
+		return nil, fmt.Errorf("unterminated section name")
+	}
+	section.Name = strings.TrimSpace(line[1:end])
+	if section.Name == "" {
+		return nil, fmt.Errorf("empty section name")
+	}
+	line = line[end+1:]
+
+	if strings.HasPrefix(line, "[") {
+		end = strings.IndexByte(line, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated approval count")
+		}
+		n, err := strconv.ParseUint(line[1:end], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid approval count %q", line[1:end])
+		}
+		section.Approvals = uint(n)
+		line = line[end+1:]
+	}
+
+	if line != "" && line[0] != ' ' && line[0] != '\t' {
+		return nil, fmt.Errorf("unexpected %q after section name", line)
+	}
+	if fields := splitFields(line); len(fields) > 0 {
+		section.DefaultOwners = fields
+	}
+	return section, nil
+}
+
+// splitFields splits a line on unescaped whitespace, stopping at an
+// unescaped '#' which begins a comment.  "\#" and "\ " are unescaped.
+func splitFields(line string) []string {
+	var out []string
+	var field strings.Builder
+	inField := false
+	flush := func() {
+		if inField {
+			out = append(out, field.String())
+			field.Reset()
+			inField = false
+		}
+	}
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == '\\' && i+1 < len(line) && (line[i+1] == '#' || line[i+1] == ' '):
+			i++
+			field.WriteByte(line[i])
+			inField = true
+		case ch == '#' && !inField:
+			flush()
+			return out
+		case ch == ' ' || ch == '\t':
+			flush()
+		default:
+			field.WriteByte(ch)
+			inField = true
+		}
+	}
+	flush()
+	return out
+}
+
+// dialect is the gitignore syntax, less the anchoring, which compilePattern
+// does itself.
+var dialect = func() glob.Dialect {
+	d := glob.Gitignore
+	d.Name = "CODEOWNERS"
+	d.MatchBase = false
+	return d
+}()
+
+// compilePattern translates a gitignore-style pattern into a Glob which
+// matches the named path and everything beneath it.
+func compilePattern(pattern string) (*glob.Glob, error) {
+	opts := []glob.Option{glob.WithDialect(dialect), glob.WithNormalization(glob.NoNormalization)}
+
+	// Check the syntax first, so that an error refers to the pattern as
+	// written rather than to its translation.
+	if _, err := glob.Compile(pattern, opts...); err != nil {
+		return nil, err
+	}
+
+	p := pattern
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return glob.Compile("**", opts...)
+	}
+	if !anchored && !strings.HasPrefix(p, "**/") && p != "**" {
+		p = "**/" + p
+	}
+
+	beneath, err := glob.Compile(p+"/**", opts...)
+	if err != nil {
+		return nil, err
+	}
+	if dirOnly {
+		return beneath, nil
+	}
+	if p == "*" || strings.HasSuffix(p, "/*") {
+		return glob.Compile(p, opts...)
+	}
+	self, err := glob.Compile(p, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return glob.Or(self, beneath), nil
+}
+
+// Glob returns the compiled form of the entry's pattern.
+func (e *Entry) Glob() *glob.Glob {
+	return e.glob
+}
+
+// Match reports whether the entry's pattern matches path.  A leading '/' on
+// path is ignored.
+func (e *Entry) Match(path string) bool {
+	return e.glob.Match(strings.TrimPrefix(path, "/"))
+}
+
+func (e *Entry) String() string {
+	return fmt.Sprintf("%d: %s", e.Line, strings.Join(append([]string{e.Pattern}, e.Owners...), " "))
+}
+
+// Match returns, for each section in which some entry matches path, the last
+// such entry and the owners it assigns.  This explains the result of Owners.
+func (f *File) Match(path string) []Match {
+	var out []Match
+	for _, section := range f.Sections {
+		for i := len(section.Entries) - 1; i >= 0; i-- {
+			entry := section.Entries[i]
+			if !entry.Match(path) {
+				continue
+			}
+			owners := entry.Owners
+			if len(owners) == 0 {
+				owners = section.DefaultOwners
+			}
+			out = append(out, Match{Entry: entry, Owners: owners})
+			break
+		}
+	}
+	return out
+}
+
+// Owners returns the owners of path, combined across sections and without
+// duplicates, in the order in which they are first listed.  It returns nil
+// if the path is unowned.
+func (f *File) Owners(path string) []string {
+	var out []string
+	seen := make(map[string]struct{})
+	for _, m := range f.Match(path) {
+		for _, owner := range m.Owners {
+			if _, found := seen[owner]; !found {
+				seen[owner] = struct{}{}
+				out = append(out, owner)
+			}
+		}
+	}
+	return out
+}
+
+var _ error = (*ParseError)(nil)
+var _ fmt.Stringer = (*Entry)(nil)
@@ -0,0 +1,144 @@
+package codeowners
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const example = `
+# Global owners.
+*       @global-owner1 @global-owner2
+
+*.js    @js-owner #This is an inline comment.
+/build/logs/ @doctocat
+docs/*  docs@example.com
+apps/   @octocat
+/scripts/ @doctocat @octocat
+\#notes @hashtag
+/vendor/ # no owners
+
+[Documentation] @docs-team
+docs/
+README.md @readme
+
+^[Security][2] @security
+**/auth/** @auth
+
+[documentation]
+*.rst
+`
+
+func TestOwners(t *testing.T) {
+	f, err := ParseString(example)
+	if err != nil {
+		t.Fatalf("Parse: unexpected error: %v", err)
+	}
+
+	type testRow struct {
+		Path   string
+		Owners []string
+		Lines  []uint
+	}
+
+	testData := [...]testRow{
+		{"main.go", []string{"@global-owner1", "@global-owner2"}, []uint{3}},
+		{"src/app.js", []string{"@js-owner"}, []uint{5}},
+		{"build/logs/today.txt", []string{"@doctocat"}, []uint{6}},
+		{"build/logs", []string{"@global-owner1", "@global-owner2"}, []uint{3}},
+		{"x/build/logs/today.txt", []string{"@global-owner1", "@global-owner2"}, []uint{3}},
+		{"docs/index.md", []string{"docs@example.com", "@docs-team"}, []uint{7, 14}},
+		{"docs/a/b.md", []string{"@global-owner1", "@global-owner2", "@docs-team"}, []uint{3, 14}},
+		{"x/apps/y/z.go", []string{"@octocat"}, []uint{8}},
+		{"/scripts/run.sh", []string{"@doctocat", "@octocat"}, []uint{9}},
+		{"#notes", []string{"@hashtag"}, []uint{10}},
+		{"vendor/lib.go", nil, []uint{11}},
+		{"sub/README.md", []string{"@global-owner1", "@global-owner2", "@readme"}, []uint{3, 15}},
+		{"src/auth/login.go", []string{"@global-owner1", "@global-owner2", "@auth"}, []uint{3, 18}},
+		{"guide.rst", []string{"@global-owner1", "@global-owner2", "@docs-team"}, []uint{3, 21}},
+	}
+
+	for _, row := range testData {
+		t.Run(row.Path, func(t *testing.T) {
+			owners := f.Owners(row.Path)
+			if !reflect.DeepEqual(owners, row.Owners) {
+				t.Errorf("Owners: expected %q, got %q", row.Owners, owners)
+			}
+			var lines []uint
+			for _, m := range f.Match(row.Path) {
+				lines = append(lines, m.Entry.Line)
+			}
+			if !reflect.DeepEqual(lines, row.Lines) {
+				t.Errorf("Match: expected lines %v, got %v", row.Lines, lines)
+			}
+		})
+	}
+
+	if n := len(f.Sections); n != 3 {
+		t.Fatalf("Sections: expected 3, got %d", n)
+	}
+	security := f.Sections[2]
+	if security.Name != "Security" || !security.Optional || security.Approvals != 2 {
+		t.Errorf("Sections[2]: unexpected %+v", security)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, text := range []string{
+		"[Unterminated",
+		"[]",
+		"[Docs][x]",
+		"[Docs]x",
+		"a[b @owner",
+	} {
+		_, err := ParseString(text)
+		if perr, ok := err.(*ParseError); !ok || perr.Line != 1 {
+			t.Errorf("%q: expected *ParseError on line 1, got %v", text, err)
+		}
+	}
+}
+
+func TestParse_GitignoreSyntax(t *testing.T) {
+	type testRow struct {
+		Pattern string
+		Path    string
+		Expect  bool
+	}
+
+	testData := [...]testRow{
+		// Braces are literal.
+		{"docs/{a}.md", "docs/{a}.md", true},
+		{"docs/{a}.md", "docs/a.md", false},
+		// "**" is special only as a whole path component.
+		{"a**b", "acb", true},
+		{"a**b", "a/c/b", false},
+		{"a/**/b", "a/c/b", true},
+		// "[!" negates a set.
+		{"x[!a].txt", "xb.txt", true},
+		{"x[!a].txt", "xa.txt", false},
+		// A trailing '/' limits even a last component of "*" to the
+		// contents of directories.
+		{"apps/*/", "apps/web/main.go", true},
+		{"apps/*/", "apps/web", false},
+		{"*/", "a/b", true},
+		{"*/", "q", false},
+		{"/", "q", true},
+	}
+
+	for _, row := range testData {
+		f, err := ParseString(row.Pattern + " @owner")
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", row.Pattern, err)
+			continue
+		}
+		if actual := f.Sections[0].Entries[0].Match(row.Path); actual != row.Expect {
+			t.Errorf("%q against %q: expected %v, got %v", row.Path, row.Pattern, row.Expect, actual)
+		}
+	}
+
+	// Errors refer to the pattern as written, not to its translation.
+	_, err := ParseString("docs/[a @owner")
+	if err == nil || !strings.Contains(err.Error(), `"docs/[a"`) || strings.Contains(err.Error(), "**") {
+		t.Errorf("expected an error about %q, got %v", "docs/[a", err)
+	}
+}
@@ -0,0 +1,666 @@
+package glob
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+type combinator byte
+
+const (
+	leafOp combinator = iota
+	orOp
+	andOp
+	notOp
+)
+
+var combinatorNames = []string{
+	"MustCompile",
+	"Or",
+	"And",
+	"Not",
+}
+
+// Or returns a Glob which matches every string matched by at least one of
+// globs.  Its Matcher reports the captures of the first operand which
+// matches.  Or with no operands matches nothing.
+//
+// A Glob built by Or, And or Not has no segments and an empty Pattern; use
+// Expr to serialize it, and CompileExpr to parse it back.
+//
+// The result is simplified where the segment structure allows: nested Ors
+// are flattened, an operand which is subsumed by an earlier one is dropped,
+// adjacent operands which differ in a single rune are merged into one
+// character set, and a literal prefix shared by every operand is checked
+// once up front.
+func Or(globs ...*Glob) *Glob {
+	if len(globs) == 0 {
+		return Not(MustCompile("**"))
+	}
+	return combine(orOp, globs)
+}
+
+// And returns a Glob which matches every string matched by all of globs.
+// Its Matcher reports the captures of the first operand.  And with no
+// operands matches everything.
+//
+// Nested Ands are flattened, and an operand which subsumes another one is
+// dropped, except for the first operand.
+func And(globs ...*Glob) *Glob {
+	if len(globs) == 0 {
+		return MustCompile("**")
+	}
+	return combine(andOp, globs)
+}
+
+// Not returns a Glob which matches every string not matched by g.  Its
+// Matcher never reports any captures.
+func Not(g *Glob) *Glob {
+	if g.op == notOp {
+		return g.operands[0]
+	}
+	return &Glob{op: notOp, operands: []*Glob{g}}
+}
+
+func combine(op combinator, globs []*Glob) *Glob {
+	// Flatten nested operators of the same kind.
+	var flat []*Glob
+	for _, g := range globs {
+		if g.op == op {
+			flat = append(flat, g.operands...)
+		} else {
+			flat = append(flat, g)
+		}
+	}
+
+	// Drop operands which cannot change the outcome.  For Or, an operand
+	// subsumed by an earlier one never gets to report its captures.  For
+	// And, an operand which subsumes another one adds no constraint; of
+	// two equivalent operands the earlier is kept, and the first operand
+	// is always kept for its captures.
+	var kept []*Glob
+	for j, g := range flat {
+		redundant := false
+		for k, other := range flat {
+			if k == j {
+				continue
+			}
+			switch op {
+			case orOp:
+				redundant = k < j && (sameGlob(other, g) || Subsumes(other, g))
+			case andOp:
+				redundant = j > 0 && Subsumes(g, other) && (k < j || !Subsumes(other, g))
+			}
+			if redundant {
+				break
+			}
+		}
+		if !redundant {
+			kept = append(kept, g)
+		}
+	}
+
+	if op == orOp {
+		kept = mergeAdjacent(kept)
+	}
+	if len(kept) == 1 {
+		return kept[0]
+	}
+
+	out := &Glob{op: op, operands: kept}
+	if op == orOp {
+		out.prefix = sharedPrefix(kept)
+	}
+	return out
+}
+
+// sameGlob returns true iff a and b are the same pattern, compiled the same
+// way.
+func sameGlob(a, b *Glob) bool {
+	if a == b {
+		return true
+	}
+	if a.op != b.op || len(a.operands) != len(b.operands) {
+		return false
+	}
+	if a.op == leafOp {
+		return a.impl.Pattern.String == b.impl.Pattern.String && a.impl.Options == b.impl.Options
+	}
+	for i := range a.operands {
+		if !sameGlob(a.operands[i], b.operands[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// atom is one rune-sized piece of a pattern: a rune of a literal, a
+// character set, or a wildcard.
+type atom struct {
+	typ guts.SegmentType
+	ch  rune
+	set guts.RuneMatcher
+}
+
+func atomsOf(g *guts.Glob) []atom {
+	var out []atom
+	for i := range g.Segments {
+		seg := &g.Segments[i]
+		switch seg.Type {
+		case guts.LiteralSegment:
+			for _, ch := range seg.Literal.Runes {
+				out = append(out, atom{typ: guts.LiteralSegment, ch: ch})
+			}
+		default:
+			out = append(out, atom{typ: seg.Type, set: seg.Matcher})
+		}
+	}
+	return out
+}
+
+// leadingDot returns true iff atoms[i] is a literal '.' at the start of a
+// path component.
+func leadingDot(atoms []atom, i int) bool {
+	if atoms[i].typ != guts.LiteralSegment || atoms[i].ch != '.' {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	prev := atoms[i-1]
+	return prev.typ == guts.DoubleStarSlashSegment || (prev.typ == guts.LiteralSegment && prev.ch == '/')
+}
+
+func (a atom) singleRune() guts.RuneMatcher {
+	switch a.typ {
+	case guts.LiteralSegment:
+		return guts.Is(a.ch)
+	case guts.RuneMatchSegment:
+		return a.set
+	default:
+		return nil
+	}
+}
+
+func (a atom) equal(b atom) bool {
+	if a.typ != b.typ {
+		return false
+	}
+	switch a.typ {
+	case guts.LiteralSegment:
+		return a.ch == b.ch
+	case guts.RuneMatchSegment:
+		return guts.EqualMatchers(a.set, b.set)
+	default:
+		return true
+	}
+}
+
+// mergeAdjacent merges neighbouring leaf operands which differ only in one
+// rune position, such as "a[bc]d" and "axd", into one character set.
+func mergeAdjacent(globs []*Glob) []*Glob {
+	out := globs[:0:0]
+	for _, g := range globs {
+		if n := len(out); n > 0 {
+			if merged := mergeLeaves(out[n-1], g); merged != nil {
+				out[n-1] = merged
+				continue
+			}
+		}
+		out = append(out, g)
+	}
+	return out
+}
+
+func mergeLeaves(a, b *Glob) *Glob {
+	if a.op != leafOp || b.op != leafOp || a.impl.Options != b.impl.Options {
+		return nil
+	}
+	x := atomsOf(&a.impl)
+	y := atomsOf(&b.impl)
+	if len(x) != len(y) {
+		return nil
+	}
+	diff := -1
+	for i := range x {
+		if x[i].equal(y[i]) {
+			continue
+		}
+		if diff >= 0 {
+			return nil
+		}
+		diff = i
+	}
+	if diff < 0 {
+		return a
+	}
+	if a.impl.Options.HideDotfiles && (leadingDot(x, diff) || leadingDot(y, diff)) {
+		// A set never matches a hidden '.', so the literal must stay.
+		return nil
+	}
+	sa := x[diff].singleRune()
+	sb := y[diff].singleRune()
+	if sa == nil || sb == nil {
+		return nil
+	}
+	x[diff] = atom{typ: guts.RuneMatchSegment, set: guts.Union(sa, sb)}
+
+	var b2 Builder
+	for _, at := range x {
+		switch at.typ {
+		case guts.LiteralSegment:
+			b2.Literal(guts.EncodeRawRunes([]rune{at.ch}))
+		case guts.RuneMatchSegment:
+			b2.Class(at.set)
+		case guts.QuestionSegment:
+			b2.Question()
+		case guts.StarSegment:
+			b2.Star()
+		case guts.DoubleStarSegment:
+			b2.DoubleStar()
+		case guts.DoubleStarSlashSegment:
+			b2.DoubleStarSlash()
+		}
+	}
+	merged, err := b2.Build(withOptions(a.impl.Options))
+	if err != nil {
+		return nil
+	}
+	return merged
+}
+
+// sharedPrefix returns the literal prefix shared by every operand, if they
+// are all leaves normalized the same way.
+func sharedPrefix(globs []*Glob) []rune {
+	var prefix []rune
+	for i, g := range globs {
+		if g.op != leafOp || g.impl.Options.Form != globs[0].impl.Options.Form {
+			return nil
+		}
+		if i == 0 {
+			prefix = g.impl.Prefix
+			continue
+		}
+		n := 0
+		for n < len(prefix) && n < len(g.impl.Prefix) && prefix[n] == g.impl.Prefix[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	if len(prefix) == 0 {
+		return nil
+	}
+	return prefix
+}
+
+// leaves appends the compiled patterns beneath g, in order.
+func (g *Glob) leaves(out []*Glob) []*Glob {
+	if g.op == leafOp {
+		return append(out, g)
+	}
+	for _, operand := range g.operands {
+		out = operand.leaves(out)
+	}
+	return out
+}
+
+// onlyOr returns true iff g is a leaf or an Or of leaves, so that it matches
+// exactly when some leaf does.
+func (g *Glob) onlyOr() bool {
+	switch g.op {
+	case leafOp:
+		return true
+	case orOp:
+		for _, operand := range g.operands {
+			if !operand.onlyOr() {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// eval combines the outcomes of the leaves beneath g, consuming one flag
+// per leaf starting at accepts[*i].
+func (g *Glob) eval(accepts []bool, i *int) bool {
+	switch g.op {
+	case orOp:
+		result := false
+		for _, operand := range g.operands {
+			result = operand.eval(accepts, i) || result
+		}
+		return result
+	case andOp:
+		result := true
+		for _, operand := range g.operands {
+			result = operand.eval(accepts, i) && result
+		}
+		return result
+	case notOp:
+		return !g.operands[0].eval(accepts, i)
+	default:
+		result := accepts[*i]
+		*i++
+		return result
+	}
+}
+
+func (g *Glob) matchCombined(match func(*Glob) bool) bool {
+	switch g.op {
+	case orOp:
+		for _, operand := range g.operands {
+			if match(operand) {
+				return true
+			}
+		}
+		return false
+	case andOp:
+		for _, operand := range g.operands {
+			if !match(operand) {
+				return false
+			}
+		}
+		return true
+	default:
+		return !match(g.operands[0])
+	}
+}
+
+func (g *Glob) appendString(buf *strings.Builder, goSyntax bool) {
+	if goSyntax {
+		buf.WriteString("glob.")
+	}
+	if g.op == leafOp {
+		if !goSyntax {
+			buf.WriteString(strconv.Quote(g.impl.Pattern.String))
+			return
+		}
+		buf.WriteString(combinatorNames[g.op])
+		buf.WriteByte('(')
+		buf.WriteString(strconv.Quote(g.impl.Pattern.String))
+		buf.WriteByte(')')
+		return
+	}
+	buf.WriteString(combinatorNames[g.op])
+	buf.WriteByte('(')
+	for i, operand := range g.operands {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		operand.appendString(buf, goSyntax)
+	}
+	buf.WriteByte(')')
+}
+
+func (g *Glob) combinedString(goSyntax bool) string {
+	var buf strings.Builder
+	g.appendString(&buf, goSyntax)
+	return buf.String()
+}
+
+// Expr returns an expression which CompileExpr parses back into an
+// equivalent Glob, such as `Or("a*", Not("b/c"))`.  It is the same as String,
+// except that a plain pattern is quoted too.
+func (g *Glob) Expr() string {
+	if g.op == leafOp {
+		return strconv.Quote(g.Pattern())
+	}
+	return g.combinedString(false)
+}
+
+// CompileExpr parses an expression written by Expr, String or GoString.
+// Each quoted operand is a pattern in native syntax, which is compiled with
+// opts; WithDialect is ignored, since the expression holds the native
+// translation of any dialect.
+func CompileExpr(expr string, opts ...Option) (*Glob, error) {
+	p := exprParser{input: expr, opts: opts[:len(opts):len(opts)]}
+	p.opts = append(p.opts, WithDialect(Native))
+	g := p.parseExpr()
+	p.skipSpace()
+	if p.err == nil && p.i < len(expr) {
+		p.fail("unexpected %q after expression", expr[p.i:])
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return g, nil
+}
+
+// exprParser is a recursive descent parser for the expressions written by
+// appendString.
+type exprParser struct {
+	input string
+	i     int
+	opts  []Option
+	err   error
+}
+
+func (p *exprParser) fail(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	p.err = &SyntaxError{
+		Kind:       UnexpectedRuneError,
+		Pattern:    p.input,
+		Message:    fmt.Sprintf(format, args...),
+		RuneOffset: uint(utf8.RuneCountInString(p.input[:p.i])),
+		ByteOffset: uint(p.i),
+		what:       "glob expression",
+	}
+}
+
+func (p *exprParser) skipSpace() {
+	for p.i < len(p.input) && strings.IndexByte(" \t\r\n", p.input[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+func (p *exprParser) consume(s string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.input[p.i:], s) {
+		p.i += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseExpr() *Glob {
+	p.skipSpace()
+	if p.i < len(p.input) && p.input[p.i] == '"' {
+		return p.parsePattern()
+	}
+
+	p.consume("glob.")
+	start := p.i
+	for p.i < len(p.input) && (p.input[p.i] >= 'A' && p.input[p.i] <= 'Z' || p.input[p.i] >= 'a' && p.input[p.i] <= 'z') {
+		p.i++
+	}
+	name := p.input[start:p.i]
+	op := leafOp
+	found := false
+	for i, candidate := range combinatorNames {
+		if name == candidate {
+			op = combinator(i)
+			found = true
+		}
+	}
+	if !found {
+		p.i = start
+		p.fail("expected a quoted pattern, Or, And or Not")
+		return nil
+	}
+	if !p.consume("(") {
+		p.fail("expected '(' after %s", name)
+		return nil
+	}
+
+	var operands []*Glob
+	for {
+		operands = append(operands, p.parseExpr())
+		if p.err != nil {
+			return nil
+		}
+		if p.consume(")") {
+			break
+		}
+		if !p.consume(",") {
+			p.fail("expected ',' or ')'")
+			return nil
+		}
+	}
+
+	switch op {
+	case orOp:
+		return Or(operands...)
+	case andOp:
+		return And(operands...)
+	}
+	if len(operands) != 1 || (op == leafOp && operands[0].op != leafOp) {
+		p.i = start
+		p.fail("%s takes exactly one pattern", name)
+		return nil
+	}
+	if op == notOp {
+		return Not(operands[0])
+	}
+	return operands[0]
+}
+
+// parsePattern compiles the Go string literal at the current position.
+func (p *exprParser) parsePattern() *Glob {
+	start := p.i
+	for p.i++; p.i < len(p.input) && p.input[p.i] != '"'; p.i++ {
+		if p.input[p.i] == '\\' {
+			p.i++
+		}
+	}
+	if p.i >= len(p.input) {
+		p.i = start
+		p.fail("unterminated string")
+		return nil
+	}
+	p.i++
+	pattern, err := strconv.Unquote(p.input[start:p.i])
+	if err != nil {
+		p.i = start
+		p.fail("invalid string: %v", err)
+		return nil
+	}
+	g, err := Compile(pattern, p.opts...)
+	if err != nil {
+		p.err = err
+		return nil
+	}
+	return g
+}
+
+// combination is the state of a Matcher for a Glob built by Or, And or Not.
+// The operands are matched in full on the first call to HasNext or OK, and
+// then the operand which supplies the captures is rewound and replayed.
+type combination struct {
+	g       *Glob
+	input   string
+	subs    []*Matcher
+	pick    *Matcher
+	decided bool
+	ok      bool
+}
+
+func (g *Glob) combinedMatcher(input string, sub func(*Glob) *Matcher) *Matcher {
+	x := &combination{g: g, input: input}
+	for _, operand := range g.operands {
+		x.subs = append(x.subs, sub(operand))
+	}
+	return &Matcher{x: x}
+}
+
+func (x *combination) reset(input string, resetSub func(*Matcher)) {
+	x.input = input
+	x.pick = nil
+	x.decided = false
+	x.ok = false
+	for _, sub := range x.subs {
+		resetSub(sub)
+	}
+}
+
+func (x *combination) decide() {
+	if x.decided {
+		return
+	}
+	x.decided = true
+
+	switch x.g.op {
+	case orOp:
+		if x.g.prefix != nil && !guts.HasPrefixRunes(x.subs[0].impl.Input.Runes, x.g.prefix) {
+			return
+		}
+		for _, sub := range x.subs {
+			if sub.Matches() {
+				x.ok = true
+				x.pick = sub
+				break
+			}
+			if sub.Err() != nil {
+				return
+			}
+		}
+
+	case andOp:
+		for _, sub := range x.subs {
+			if !sub.Matches() {
+				return
+			}
+		}
+		x.ok = true
+		x.pick = x.subs[0]
+
+	case notOp:
+		sub := x.subs[0]
+		x.ok = !sub.Matches() && sub.Err() == nil
+	}
+
+	if x.pick != nil {
+		// Rewind, so that HasNext replays the captures.
+		x.pick.Reset(x.input)
+	}
+}
+
+func (x *combination) failure() *Failure {
+	x.decide()
+	if x.ok {
+		return nil
+	}
+	switch x.g.op {
+	case orOp:
+		var best *Failure
+		for _, sub := range x.subs {
+			if f := sub.Failure(); f != nil && (best == nil || f.RuneOffset > best.RuneOffset) {
+				best = f
+			}
+		}
+		return best
+	case andOp:
+		for _, sub := range x.subs {
+			if f := sub.Failure(); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+func (x *combination) err() error {
+	for _, sub := range x.subs {
+		if err := sub.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestCombine(t *testing.T) {
+	goFiles := MustCompile("**/*.go")
+	testFiles := MustCompile("**/*_test.go")
+	vendor := MustCompile("vendor/**")
+
+	type testRow struct {
+		Glob     *Glob
+		String   string
+		Matches  []string
+		Rejects  []string
+		Captures string
+	}
+
+	testData := [...]testRow{
+		{
+			Glob:    Or(goFiles, vendor),
+			String:  `Or("**/*.go", "vendor/**")`,
+			Matches: []string{"a.go", "vendor/x.c"},
+			Rejects: []string{"a.c"},
+		},
+		{
+			Glob:    And(goFiles, Not(testFiles)),
+			String:  `And("**/*.go", Not("**/*_test.go"))`,
+			Matches: []string{"a.go", "src/b.go"},
+			Rejects: []string{"a_test.go", "a.c"},
+		},
+		{
+			Glob:    And(goFiles, Not(testFiles), Not(vendor)),
+			String:  `And("**/*.go", Not("**/*_test.go"), Not("vendor/**"))`,
+			Matches: []string{"a.go"},
+			Rejects: []string{"vendor/a.go", "a_test.go"},
+		},
+		{
+			// Flattened, and the subsumed operand dropped.
+			Glob:   Or(Or(goFiles, vendor), testFiles),
+			String: `Or("**/*.go", "vendor/**")`,
+		},
+		{
+			// The wider operand adds nothing.
+			Glob:   And(testFiles, goFiles),
+			String: `**/*_test.go`,
+		},
+		{
+			Glob:   Not(Not(goFiles)),
+			String: `**/*.go`,
+		},
+		{
+			// Merged into a single character set.
+			Glob:    Or(MustCompile("a[bc]d"), MustCompile("axd"), MustCompile("aed")),
+			String:  `a[bcex]d`,
+			Matches: []string{"abd", "aed", "axd"},
+			Rejects: []string{"add"},
+		},
+		{
+			Glob:    Or(MustCompile("src/*.go"), MustCompile("src/*.c")),
+			String:  `Or("src/*.go", "src/*.c")`,
+			Matches: []string{"src/a.go", "src/a.c"},
+			Rejects: []string{"lib/a.c", "src/a.h"},
+		},
+		{
+			Glob:    Or(),
+			String:  `Not("**")`,
+			Rejects: []string{"", "a"},
+		},
+		{
+			Glob:    And(),
+			String:  `**`,
+			Matches: []string{"", "a"},
+		},
+	}
+
+	for _, row := range testData {
+		t.Run(row.String, func(t *testing.T) {
+			if str := row.Glob.String(); str != row.String {
+				t.Errorf("String: expected %q, got %q", row.String, str)
+			}
+			for _, input := range row.Matches {
+				if !row.Glob.Match(input) {
+					t.Errorf("Match(%q): expected true", input)
+				}
+				if !row.Glob.Matcher(input).Matches() {
+					t.Errorf("Matcher(%q).Matches(): expected true", input)
+				}
+			}
+			for _, input := range row.Rejects {
+				if row.Glob.Match(input) {
+					t.Errorf("Match(%q): expected false", input)
+				}
+				m := row.Glob.Matcher(input)
+				if m.Matches() {
+					t.Errorf("Matcher(%q).Matches(): expected false", input)
+				}
+			}
+		})
+	}
+}
+
+func TestCombine_HideDotfiles(t *testing.T) {
+	// Merging ".x" and "ax" into "[.a]x" would hide ".x".
+	g := Or(MustCompile(".x", WithDialect(Bash)), MustCompile("ax", WithDialect(Bash)))
+	for _, input := range []string{".x", "ax"} {
+		if !g.Match(input) {
+			t.Errorf("expected %q to match %v", input, g)
+		}
+	}
+	if g.Match("bx") {
+		t.Errorf("expected %q not to match %v", "bx", g)
+	}
+}
+
+func TestCombine_Matcher(t *testing.T) {
+	g := Or(MustCompile("*.c"), MustCompile("src/*.go"))
+
+	m := g.Matcher("src/main.go")
+	var captures []string
+	for m.HasNext() {
+		captures = append(captures, m.Capture().Input())
+	}
+	if !m.OK() {
+		t.Fatalf("expected match")
+	}
+	if len(captures) != 3 || captures[1] != "main" {
+		t.Errorf("captures: expected [src/ main .go], got %q", captures)
+	}
+
+	m.Reset("src/main.h")
+	if m.Matches() {
+		t.Errorf("Reset: expected no match")
+	}
+	if f := m.Failure(); f == nil || f.Matched != "src/main.h" {
+		t.Errorf("Failure: expected the furthest operand, got %v", f)
+	}
+
+	g = And(MustCompile("**/*.go"), Not(MustCompile("vendor/**")))
+	if s := g.GoString(); s != `glob.And(glob.MustCompile("**/*.go"), glob.Not(glob.MustCompile("vendor/**")))` {
+		t.Errorf("GoString: unexpected %s", s)
+	}
+	if !Subsumes(MustCompile("**/*.go"), g) {
+		t.Errorf("Subsumes: expected **/*.go to subsume %v", g)
+	}
+	if ok, example := Overlaps(g, MustCompile("vendor/*")); ok {
+		t.Errorf("Overlaps: expected none, got %q", example)
+	}
+}
+
+func TestCombine_Expr(t *testing.T) {
+	a := MustCompile("a*")
+	b := MustCompile("b/c")
+	or := Or(a, b)
+	if s := or.PatternSubstring(0, 0); s != "" {
+		t.Errorf("PatternSubstring: expected \"\", got %q", s)
+	}
+	if s := or.Pattern(); s != "" {
+		t.Errorf("Pattern: expected \"\", got %q", s)
+	}
+
+	globs := []*Glob{
+		a,
+		MustCompile(`x"\*y`),
+		or,
+		Not(or),
+		And(MustCompile("**/*.go"), Not(MustCompile("vendor/**"))),
+	}
+	for _, g := range globs {
+		for _, s := range []string{g.Expr(), g.GoString()} {
+			parsed, err := CompileExpr(s)
+			if err != nil {
+				t.Errorf("CompileExpr(%q): unexpected error: %v", s, err)
+				continue
+			}
+			if !Equivalent(parsed, g) {
+				t.Errorf("CompileExpr(%q): got %v, not equivalent to %v", s, parsed, g)
+			}
+		}
+	}
+	if parsed, err := CompileExpr(or.String()); err != nil || parsed.Expr() != or.Expr() {
+		t.Errorf("CompileExpr(%q): got %v, %v", or.String(), parsed, err)
+	}
+
+	for _, s := range []string{`Or("a"`, `Xor("a")`, `Not("a", "b")`, `"a" "b"`, `Or("[a")`, `"a`} {
+		if _, err := CompileExpr(s); err == nil {
+			t.Errorf("CompileExpr(%q): expected an error", s)
+		} else if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("CompileExpr(%q): expected *SyntaxError, got %T", s, err)
+		}
+	}
+}
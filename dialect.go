@@ -0,0 +1,1003 @@
+package glob
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// Dialect describes the syntax and matching rules of a foreign glob
+// dialect.  Compile translates a pattern written in a dialect into the
+// native syntax described in the package documentation, so the result
+// behaves like any other Glob; its Pattern and String methods return the
+// native translation.
+//
+// The zero Dialect is Native.  In every other dialect, the escape character
+// makes the following rune literal, and a ']', '{', '}' or '(' which has no
+// special meaning is literal.
+type Dialect struct {
+	// Name identifies the dialect in error messages.
+	Name string
+
+	// Escape is the rune which makes the following rune literal.  If zero,
+	// it is '\\'; NoEscape means that there is none.
+	Escape rune
+
+	// BangNegation accepts "[!...]" as well as "[^...]" for a negated
+	// character set.
+	BangNegation bool
+
+	// Braces enables "{a,b,c}" alternatives, which may nest, and "{1..10}"
+	// ranges of decimal integers, which may be negative or descending.  A
+	// pattern with braces is expanded into one pattern per alternative,
+	// and the results are combined as if by Or.  A range becomes one
+	// alternative per run of digit ranges, such as "1[0-9][0-9]" for 100
+	// to 199, rather than one per integer, so its size does not matter.
+	// Braces which contain neither a top-level ',' nor a range are
+	// literal.
+	Braces bool
+
+	// Extglob enables "@(a|b)", which matches one of the alternatives, and
+	// "?(a|b)", which matches one of them or nothing; these are expanded
+	// like braces.  "!(a|b)", which matches anything within a path
+	// component except the alternatives, is supported only in a pattern
+	// which is otherwise literal.  "*(...)" and "+(...)" are rejected with
+	// an UnsupportedSyntaxError.
+	Extglob bool
+
+	// DoubleStar selects where "**" matches across '/'.
+	DoubleStar DoubleStarMode
+
+	// Separator selects how wildcards treat '/'.
+	Separator SeparatorMode
+
+	// HideDotfiles makes a path component of the input which begins with
+	// '.' match only a component of the pattern which begins with a
+	// literal '.'.  In particular, "*", "?", character sets and "**" never
+	// match a leading '.'.
+	HideDotfiles bool
+
+	// MatchBase makes a pattern which contains no '/', other than at its
+	// end, match at any depth, as if it began with "**/".  A leading '/'
+	// is removed from any other pattern, anchoring it.
+	MatchBase bool
+
+	// StrictSets rejects a '-' or ']' in a character set which is not
+	// escaped and is not in its usual place, as path.Match does: a set
+	// must have at least one member, and '-' may only join the two ends
+	// of a range.  A backwards range, such as "[b-a]", then matches
+	// nothing instead of being an error.
+	StrictSets bool
+
+	// Lenient treats a '[' which does not begin a well-formed character
+	// set and a trailing escape character as literals, instead of failing
+	// to compile.  With ExplicitSeparator, a set which would contain '/'
+	// is not well-formed.
+	Lenient bool
+}
+
+// NoEscape is the Dialect.Escape of dialects without an escape character.
+const NoEscape rune = -1
+
+// DoubleStarMode selects where "**" matches across '/'.
+type DoubleStarMode byte
+
+const (
+	// DoubleStarAnywhere makes "**" match any string, wherever it
+	// appears.  "**/" also matches the empty string.
+	DoubleStarAnywhere DoubleStarMode = iota
+
+	// DoubleStarAlone makes "**" match any string only when it is a whole
+	// path component, as in "a/**/b"; elsewhere it is the same as "*".
+	DoubleStarAlone
+
+	// DoubleStarNever makes "**" the same as "*".
+	DoubleStarNever
+
+	// DoubleStarSlash is like DoubleStarAlone, except that "**" must also
+	// be followed by '/', as in "a/**/b"; at the end of the pattern it is
+	// the same as "*".
+	DoubleStarSlash
+)
+
+var doubleStarModeNames = []string{
+	"DoubleStarAnywhere",
+	"DoubleStarAlone",
+	"DoubleStarNever",
+	"DoubleStarSlash",
+}
+
+func (x DoubleStarMode) String() string {
+	if uint(x) >= uint(len(doubleStarModeNames)) {
+		return fmt.Sprintf("%%!DoubleStarMode(%d)", x)
+	}
+	return doubleStarModeNames[x]
+}
+
+func (x DoubleStarMode) GoString() string {
+	if uint(x) >= uint(len(doubleStarModeNames)) {
+		return fmt.Sprintf("DoubleStarMode(%d)", x)
+	}
+	return doubleStarModeNames[x]
+}
+
+// SeparatorMode selects how wildcards treat '/'.
+type SeparatorMode byte
+
+const (
+	// NativeSeparator makes "*" and "?" stop at '/', while character sets
+	// may match it.
+	NativeSeparator SeparatorMode = iota
+
+	// ExplicitSeparator makes '/' match only a literal '/'.
+	ExplicitSeparator
+
+	// OrdinarySeparator makes '/' an ordinary rune, which "*", "?" and
+	// character sets all match.
+	OrdinarySeparator
+)
+
+var separatorModeNames = []string{
+	"NativeSeparator",
+	"ExplicitSeparator",
+	"OrdinarySeparator",
+}
+
+func (x SeparatorMode) String() string {
+	if uint(x) >= uint(len(separatorModeNames)) {
+		return fmt.Sprintf("%%!SeparatorMode(%d)", x)
+	}
+	return separatorModeNames[x]
+}
+
+func (x SeparatorMode) GoString() string {
+	if uint(x) >= uint(len(separatorModeNames)) {
+		return fmt.Sprintf("SeparatorMode(%d)", x)
+	}
+	return separatorModeNames[x]
+}
+
+// The presets below follow each tool's default settings.  To change one,
+// copy it and set the field, e.g. for Bash with "shopt -s globstar":
+//
+//	d := glob.Bash
+//	d.DoubleStar = glob.DoubleStarAlone
+var (
+	// Native is the syntax described in the package documentation.  It is
+	// the default.
+	Native = Dialect{Name: "native"}
+
+	// Bash is the syntax of Bash pathname expansion, including brace
+	// expansion.
+	Bash = Dialect{
+		Name:         "Bash",
+		BangNegation: true,
+		Braces:       true,
+		DoubleStar:   DoubleStarNever,
+		Separator:    ExplicitSeparator,
+		HideDotfiles: true,
+		Lenient:      true,
+	}
+
+	// Zsh is the syntax of zsh filename generation, including brace
+	// expansion and "**/".
+	Zsh = Dialect{
+		Name:         "zsh",
+		BangNegation: true,
+		Braces:       true,
+		DoubleStar:   DoubleStarSlash,
+		Separator:    ExplicitSeparator,
+		HideDotfiles: true,
+	}
+
+	// POSIX is the syntax of fnmatch(3) without flags, in which '/' is an
+	// ordinary rune.  Python's fnmatch module is the same except that it
+	// has no escape character; for it, copy POSIX and set Escape to
+	// NoEscape.
+	POSIX = Dialect{
+		Name:         "POSIX",
+		BangNegation: true,
+		DoubleStar:   DoubleStarNever,
+		Separator:    OrdinarySeparator,
+		Lenient:      true,
+	}
+
+	// PathMatch is the syntax of Go's path.Match.
+	PathMatch = Dialect{
+		Name:       "path.Match",
+		DoubleStar: DoubleStarNever,
+		StrictSets: true,
+	}
+
+	// Gitignore is the syntax of .gitignore files.  The dialect does not
+	// know which paths are directories, so a trailing '/', which limits
+	// a pattern to directories, must be handled by the caller.
+	Gitignore = Dialect{
+		Name:         "gitignore",
+		BangNegation: true,
+		DoubleStar:   DoubleStarAlone,
+		Separator:    ExplicitSeparator,
+		MatchBase:    true,
+	}
+
+	// Minimatch is the syntax of the npm minimatch package, as used by
+	// most JavaScript tools.  Of minimatch's extglobs, "*(...)" and
+	// "+(...)" are not supported and fail to compile with an
+	// UnsupportedSyntaxError, and "!(...)" is limited as described for
+	// Dialect.Extglob.
+	Minimatch = Dialect{
+		Name:         "minimatch",
+		BangNegation: true,
+		Braces:       true,
+		Extglob:      true,
+		DoubleStar:   DoubleStarAlone,
+		Separator:    ExplicitSeparator,
+		HideDotfiles: true,
+		Lenient:      true,
+	}
+
+	// EditorConfig is the syntax of section names in .editorconfig files.
+	// Paths are matched relative to the directory of the .editorconfig
+	// file.
+	EditorConfig = Dialect{
+		Name:         "EditorConfig",
+		BangNegation: true,
+		Braces:       true,
+		Separator:    ExplicitSeparator,
+		MatchBase:    true,
+		Lenient:      true,
+	}
+)
+
+// WithDialect selects the syntax of the pattern.
+func WithDialect(d Dialect) Option {
+	return func(opts *options) {
+		opts.dialect = d
+	}
+}
+
+func (d Dialect) isNative() bool {
+	d.Name = ""
+	return d == Dialect{}
+}
+
+func (d Dialect) escape() rune {
+	if d.Escape == 0 {
+		return '\\'
+	}
+	return d.Escape
+}
+
+func (d Dialect) String() string {
+	return d.Name
+}
+
+var _ fmt.Stringer = Dialect{}
+var _ fmt.Stringer = DoubleStarMode(0)
+var _ fmt.GoStringer = DoubleStarMode(0)
+var _ fmt.Stringer = SeparatorMode(0)
+var _ fmt.GoStringer = SeparatorMode(0)
+
+type tokenKind byte
+
+const (
+	literalToken tokenKind = iota
+	starToken
+	globstarToken
+	questionToken
+	setToken
+	notToken
+)
+
+// token is one element of a translated pattern.  src is its offset in the
+// original pattern; a setToken holds its native syntax in set, and the
+// offset of each rune of it in setSrc.
+type token struct {
+	kind   tokenKind
+	ch     rune
+	set    []rune
+	setSrc []uint
+	src    uint
+}
+
+// fragment is a translated pattern, or a piece of one.
+type fragment []token
+
+func (f fragment) concat(g fragment) fragment {
+	out := make(fragment, 0, len(f)+len(g))
+	return append(append(out, f...), g...)
+}
+
+// replace returns f with its notToken replaced by g.
+func (f fragment) replace(g fragment) fragment {
+	out := make(fragment, 0, len(f)+len(g))
+	for _, tok := range f {
+		if tok.kind == notToken {
+			out = append(out, g...)
+		} else {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+// dialectParser translates a pattern in some Dialect into native patterns,
+// one per brace or extglob alternative.
+type dialectParser struct {
+	d        Dialect
+	input    guts.ExplodedString
+	max      uint
+	depth    uint
+	negation []fragment
+	notAt    uint
+	err      *SyntaxError
+}
+
+func compileDialect(input string, o options) (*Glob, error) {
+	p := dialectParser{
+		d:     o.dialect,
+		input: guts.Normalize(o.impl.Form, input),
+		max:   o.impl.Limits.MaxExpansion,
+	}
+	runes := p.input.Runes
+	n := uint(len(runes))
+	if max := o.impl.Limits.MaxPatternLength; max != 0 && n > max {
+		p.fail(guts.LimitError, max, "pattern is longer than the limit of %d runes", max)
+		return nil, p.err
+	}
+
+	var prefix fragment
+	i := uint(0)
+	if p.d.MatchBase {
+		body := runes
+		if n > 0 && runes[n-1] == '/' {
+			body = runes[:n-1]
+		}
+		if !containsRune(body, '/') {
+			prefix = fragment{{kind: globstarToken}, {kind: literalToken, ch: '/'}}
+		} else if runes[0] == '/' {
+			i = 1
+		}
+	}
+
+	alts := p.parse(i, n)
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	// The length limit applies to the pattern as written, not to its
+	// translation.
+	impl := o.impl
+	impl.Limits.MaxPatternLength = 0
+	impl.HideDotfiles = p.d.HideDotfiles
+
+	if p.negation == nil {
+		return p.compile(prefix, alts, impl)
+	}
+
+	// "!(x)" matches what "*" matches, unless x matches there instead.
+	// That is only well defined if the rest of the pattern is literal, so
+	// that there is only one place where the "*" could be.
+	for _, alt := range alts {
+		for _, tok := range alt {
+			if tok.kind != literalToken && tok.kind != notToken {
+				p.fail(guts.UnsupportedSyntaxError, p.notAt, "extglob \"!(...)\" is only supported in an otherwise literal pattern")
+				return nil, p.err
+			}
+		}
+	}
+	var positive, negative []fragment
+	for _, alt := range alts {
+		positive = append(positive, alt.replace(fragment{{kind: starToken, src: p.notAt}}))
+		for _, excluded := range p.negation {
+			negative = append(negative, alt.replace(excluded))
+		}
+	}
+	pos, err := p.compile(prefix, positive, impl)
+	if err != nil {
+		return nil, err
+	}
+	neg, err := p.compile(prefix, negative, impl)
+	if err != nil {
+		return nil, err
+	}
+	return And(pos, Not(neg)), nil
+}
+
+// compile compiles each translated alternative, and combines the results.
+func (p *dialectParser) compile(prefix fragment, alts []fragment, impl guts.Options) (*Glob, error) {
+	globs := make([]*Glob, 0, len(alts))
+	for _, alt := range alts {
+		runes, src := p.render(prefix.concat(alt))
+		g := new(Glob)
+		if err := g.impl.CompileWith(string(runes), impl); err != nil {
+			return nil, p.translateError(err.(*guts.ParseError), src)
+		}
+		globs = append(globs, g)
+	}
+	return union(globs), nil
+}
+
+func containsRune(runes []rune, ch rune) bool {
+	for _, r := range runes {
+		if r == ch {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *dialectParser) fail(kind guts.ErrorKind, offset uint, format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	what := "glob pattern"
+	if p.d.Name != "" {
+		what = p.d.Name + " glob pattern"
+	}
+	p.err = &SyntaxError{
+		Kind:       ErrorKind(kind),
+		Pattern:    p.input.String,
+		Message:    fmt.Sprintf(format, args...),
+		RuneOffset: offset,
+		ByteOffset: p.input.Map[offset],
+		what:       what,
+	}
+}
+
+// translateError reports an error in a native translation at the
+// corresponding offset of the original pattern.
+func (p *dialectParser) translateError(err *guts.ParseError, src []uint) *SyntaxError {
+	offset := uint(len(p.input.Runes))
+	if err.RuneOffset < uint(len(src)) {
+		offset = src[err.RuneOffset]
+	}
+	p.fail(err.Kind, offset, "%s", err.Message)
+	return p.err
+}
+
+// render writes a translated pattern in native syntax, along with the
+// offset in the original pattern of each rune.
+func (p *dialectParser) render(f fragment) ([]rune, []uint) {
+	var runes []rune
+	var src []uint
+	add := func(offset uint, native ...rune) {
+		runes = append(runes, native...)
+		for range native {
+			src = append(src, offset)
+		}
+	}
+
+	ordinary := p.d.Separator == OrdinarySeparator
+	afterStars := false
+	for i := 0; i < len(f); i++ {
+		tok := f[i]
+		wasStars := afterStars
+		afterStars = false
+		switch tok.kind {
+		case starToken, globstarToken:
+			// The native syntax cannot write two stars apart, so a run
+			// of them is merged.
+			double := ordinary || tok.kind == globstarToken
+			for i+1 < len(f) && (f[i+1].kind == starToken || f[i+1].kind == globstarToken) {
+				i++
+				double = double || f[i].kind == globstarToken
+			}
+			if double {
+				add(tok.src, '*', '*')
+			} else {
+				add(tok.src, '*')
+			}
+			afterStars = ordinary
+
+		case questionToken:
+			if ordinary {
+				add(tok.src, []rune(`[\0-\U0010ffff]`)...)
+			} else {
+				add(tok.src, '?')
+			}
+
+		case setToken:
+			runes = append(runes, tok.set...)
+			src = append(src, tok.setSrc...)
+
+		case literalToken:
+			if tok.ch == '/' && wasStars {
+				// Keep "**/" from matching the empty string.
+				add(tok.src, '[', '/', ']')
+			} else {
+				add(tok.src, guts.SafeAppendRune(nil, tok.ch)...)
+			}
+		}
+	}
+	return runes, src
+}
+
+// parse translates runes [i, j) of the pattern, returning one fragment per
+// alternative.
+func (p *dialectParser) parse(i, j uint) []fragment {
+	runes := p.input.Runes
+	esc := p.d.escape()
+	out := []fragment{nil}
+	emit := func(tok token) {
+		for k := range out {
+			out[k] = append(out[k], tok)
+		}
+	}
+
+	for i < j && p.err == nil {
+		ch := runes[i]
+		if p.d.Extglob && i+1 < j && runes[i+1] == '(' && strings.ContainsRune("?*+@!", ch) {
+			if alts, k, ok := p.parseExtglob(i, j); ok {
+				out = p.product(out, alts, i)
+				i = k
+				continue
+			}
+			if p.err != nil {
+				return nil
+			}
+		}
+
+		switch {
+		case ch == esc:
+			if i+1 < j {
+				emit(token{kind: literalToken, ch: runes[i+1], src: i})
+				i += 2
+				continue
+			}
+			if !p.d.Lenient {
+				p.fail(guts.UnterminatedEscapeError, i, "unterminated escape")
+				return nil
+			}
+			emit(token{kind: literalToken, ch: ch, src: i})
+
+		case ch == '*':
+			k := i
+			for k < j && runes[k] == '*' {
+				k++
+			}
+			kind := starToken
+			if k-i > 1 {
+				switch p.d.DoubleStar {
+				case DoubleStarAnywhere:
+					kind = globstarToken
+				case DoubleStarAlone:
+					if (i == 0 || runes[i-1] == '/') && (k == j || runes[k] == '/') {
+						kind = globstarToken
+					}
+				case DoubleStarSlash:
+					if (i == 0 || runes[i-1] == '/') && k < j && runes[k] == '/' {
+						kind = globstarToken
+					}
+				}
+			}
+			emit(token{kind: kind, src: i})
+			i = k
+			continue
+
+		case ch == '?':
+			emit(token{kind: questionToken, src: i})
+
+		case ch == '[':
+			if set, k, ok := p.parseSet(i, j); ok {
+				emit(set)
+				i = k
+				continue
+			}
+			if p.err != nil {
+				return nil
+			}
+			emit(token{kind: literalToken, ch: ch, src: i})
+
+		case ch == '{' && p.d.Braces:
+			if alts, k, ok := p.parseBraces(i, j); ok {
+				out = p.product(out, alts, i)
+				i = k
+				continue
+			}
+			emit(token{kind: literalToken, ch: ch, src: i})
+
+		default:
+			emit(token{kind: literalToken, ch: ch, src: i})
+		}
+		i++
+	}
+	if p.err != nil {
+		return nil
+	}
+	return out
+}
+
+// parseSet translates the character set which begins at runes[i], and
+// returns the offset just past its ']'.
+func (p *dialectParser) parseSet(i, j uint) (token, uint, bool) {
+	runes := p.input.Runes
+	explicit := p.d.Separator == ExplicitSeparator
+	tok := token{kind: setToken, src: i}
+	add := func(offset uint, native ...rune) {
+		tok.set = append(tok.set, native...)
+		for range native {
+			tok.setSrc = append(tok.setSrc, offset)
+		}
+	}
+	addRange := func(lo, hi rune, loAt, hiAt uint) {
+		add(loAt, guts.SafeAppendRune(nil, lo)...)
+		if hi != lo {
+			add(hiAt, '-')
+			add(hiAt, guts.SafeAppendRune(nil, hi)...)
+		}
+	}
+
+	add(i, '[')
+	k := i + 1
+	negate := false
+	if k < j && (runes[k] == '^' || (p.d.BangNegation && runes[k] == '!')) {
+		negate = true
+		add(k, '^')
+		k++
+	}
+
+	// A ']' immediately after the '[' or the negation is a member.
+	first := true
+	for k < j {
+		if runes[k] == ']' && !first {
+			if negate && explicit {
+				add(k, '/')
+			}
+			add(k, ']')
+			return tok, k + 1, true
+		}
+		first = false
+
+		loAt := k
+		if p.d.StrictSets && (runes[k] == '-' || runes[k] == ']') {
+			p.fail(guts.UnexpectedRuneError, k, "unexpected '%c' in character set", runes[k])
+			return token{}, 0, false
+		}
+		lo, next, ok := p.setRune(k, j)
+		if !ok {
+			break
+		}
+		k = next
+		hi, hiAt := lo, loAt
+		isRange := false
+		if p.d.StrictSets && k+1 < j && runes[k] == '-' && runes[k+1] == '-' {
+			p.fail(guts.UnexpectedRuneError, k+1, "unexpected '-' in character set")
+			return token{}, 0, false
+		}
+		if k+1 < j && runes[k] == '-' && runes[k+1] != ']' {
+			hiAt = k + 1
+			hi, next, ok = p.setRune(k+1, j)
+			if !ok {
+				break
+			}
+			k = next
+			isRange = true
+		}
+
+		if explicit && lo <= '/' && '/' <= hi {
+			if p.d.Lenient {
+				return token{}, 0, false
+			}
+			if !negate {
+				// '/' is matched only by a literal '/'.
+				if lo < '/' {
+					addRange(lo, '/'-1, loAt, hiAt)
+				}
+				if hi > '/' {
+					addRange('/'+1, hi, loAt, hiAt)
+				}
+				continue
+			}
+		}
+		if isRange && lo > hi && p.d.StrictSets {
+			continue
+		}
+		if isRange {
+			// Keep a backwards range, so that it is reported.
+			add(loAt, guts.SafeAppendRune(nil, lo)...)
+			add(hiAt, '-')
+			add(hiAt, guts.SafeAppendRune(nil, hi)...)
+		} else {
+			addRange(lo, hi, loAt, hiAt)
+		}
+	}
+
+	if !p.d.Lenient {
+		p.fail(guts.UnterminatedSetError, i, "unterminated character set")
+	}
+	return token{}, 0, false
+}
+
+// setRune returns the member of a character set at runes[k], which may be
+// escaped, and the offset just past it.
+func (p *dialectParser) setRune(k, j uint) (rune, uint, bool) {
+	runes := p.input.Runes
+	if runes[k] != p.d.escape() {
+		return runes[k], k + 1, true
+	}
+	if k+1 < j {
+		return runes[k+1], k + 2, true
+	}
+	return 0, 0, false
+}
+
+// matchGroup finds the close rune which matches the open rune at runes[i],
+// and the top-level separators in between.
+func (p *dialectParser) matchGroup(i, j uint, open, close, sep rune) (uint, []uint, bool) {
+	runes := p.input.Runes
+	esc := p.d.escape()
+	var seps []uint
+	depth := 0
+	for k := i + 1; k < j; k++ {
+		switch runes[k] {
+		case esc:
+			k++
+		case open:
+			depth++
+		case close:
+			if depth == 0 {
+				return k, seps, true
+			}
+			depth--
+		case sep:
+			if depth == 0 {
+				seps = append(seps, k)
+			}
+		}
+	}
+	return 0, nil, false
+}
+
+// alternatives translates each of the alternatives between runes[i] and
+// runes[end], which are separated at seps.
+func (p *dialectParser) alternatives(i, end uint, seps []uint) []fragment {
+	p.depth++
+	defer func() { p.depth-- }()
+
+	var alts []fragment
+	start := i + 1
+	for _, k := range append(seps, end) {
+		alts = append(alts, p.parse(start, k)...)
+		if p.err != nil {
+			return nil
+		}
+		if p.max != 0 && uint(len(alts)) > p.max {
+			p.fail(guts.LimitError, i, "pattern expands to more than the limit of %d patterns", p.max)
+			return nil
+		}
+		start = k + 1
+	}
+	return alts
+}
+
+// parseBraces expands the brace group which begins at runes[i], and returns
+// the offset just past its '}'.  It returns false if the braces are literal.
+func (p *dialectParser) parseBraces(i, j uint) ([]fragment, uint, bool) {
+	end, commas, ok := p.matchGroup(i, j, '{', '}', ',')
+	if !ok {
+		return nil, 0, false
+	}
+	if len(commas) == 0 {
+		alts, ok := p.parseRange(i, end)
+		return alts, end + 1, ok
+	}
+	alts := p.alternatives(i, end, commas)
+	return alts, end + 1, p.err == nil
+}
+
+// parseExtglob expands the extglob which begins at runes[i], such as
+// "@(a|b)", and returns the offset just past its ')'.  It returns false if
+// the parentheses are literal.
+func (p *dialectParser) parseExtglob(i, j uint) ([]fragment, uint, bool) {
+	runes := p.input.Runes
+	end, bars, ok := p.matchGroup(i+1, j, '(', ')', '|')
+	if !ok {
+		return nil, 0, false
+	}
+
+	switch runes[i] {
+	case '*', '+':
+		p.fail(guts.UnsupportedSyntaxError, i, "extglob \"%c(...)\" is not supported", runes[i])
+		return nil, 0, false
+
+	case '!':
+		if p.depth > 0 || p.negation != nil {
+			p.fail(guts.UnsupportedSyntaxError, i, "extglob \"!(...)\" is only supported in an otherwise literal pattern")
+			return nil, 0, false
+		}
+		p.negation = p.alternatives(i+1, end, bars)
+		p.notAt = i
+		return []fragment{{{kind: notToken, src: i}}}, end + 1, p.err == nil
+
+	case '?':
+		alts := p.alternatives(i+1, end, bars)
+		return append([]fragment{nil}, alts...), end + 1, p.err == nil
+
+	default:
+		alts := p.alternatives(i+1, end, bars)
+		return alts, end + 1, p.err == nil
+	}
+}
+
+// parseRange expands a numeric range such as "{1..10}", whose braces are at
+// runes[i] and runes[end].
+func (p *dialectParser) parseRange(i, end uint) ([]fragment, bool) {
+	text := string(p.input.Runes[i+1 : end])
+	dots := strings.Index(text, "..")
+	if dots < 0 {
+		return nil, false
+	}
+	lo, ok1 := parseInteger(text[:dots])
+	hi, ok2 := parseInteger(text[dots+2:])
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+
+	// Rather than one alternative per integer, there is one per run of
+	// digit ranges, such as "1[0-9][0-9]" for 100 to 199, so that the
+	// size of the range does not matter.
+	var alts []fragment
+	add := func(minus bool, runs [][2]byte) {
+		var alt fragment
+		if minus {
+			alt = append(alt, token{kind: literalToken, ch: '-', src: i})
+		}
+		for _, r := range runs {
+			alt = append(alt, digitToken(r[0], r[1], i))
+		}
+		alts = append(alts, alt)
+	}
+	if lo < 0 {
+		// The magnitudes, computed so that math.MinInt64 does not
+		// overflow.
+		top := uint64(-(lo + 1)) + 1
+		bottom := uint64(1)
+		if hi < 0 {
+			bottom = uint64(-(hi + 1)) + 1
+		}
+		for _, runs := range digitRuns(bottom, top) {
+			add(true, runs)
+		}
+	}
+	if hi >= 0 {
+		bottom := uint64(0)
+		if lo > 0 {
+			bottom = uint64(lo)
+		}
+		for _, runs := range digitRuns(bottom, uint64(hi)) {
+			add(false, runs)
+		}
+	}
+
+	if p.max != 0 && uint64(len(alts)) > uint64(p.max) {
+		p.fail(guts.LimitError, i, "pattern expands to more than the limit of %d patterns", p.max)
+		return nil, false
+	}
+	return alts, true
+}
+
+// digitToken returns a token which matches one decimal digit from lo to hi.
+func digitToken(lo, hi byte, src uint) token {
+	if lo == hi {
+		return token{kind: literalToken, ch: rune(lo), src: src}
+	}
+	set := []rune{'[', rune(lo), '-', rune(hi), ']'}
+	return token{kind: setToken, set: set, setSrc: []uint{src, src, src, src, src}, src: src}
+}
+
+// digitRuns returns sequences of digit ranges which together match the
+// decimal representation, without leading zeros, of each integer from lo to
+// hi inclusive, and nothing else.
+func digitRuns(lo, hi uint64) [][][2]byte {
+	var out [][][2]byte
+	a := strconv.FormatUint(lo, 10)
+	b := strconv.FormatUint(hi, 10)
+	for n := len(a); n <= len(b); n++ {
+		// The integers with n digits.
+		first, last := a, b
+		if n > len(a) {
+			first = "1" + strings.Repeat("0", n-1)
+		}
+		if n < len(b) {
+			last = strings.Repeat("9", n)
+		}
+		out = appendDigitRuns(out, nil, first, last)
+	}
+	return out
+}
+
+// appendDigitRuns appends to out the sequences for the strings of digits
+// from a to b, which have the same length, each prefixed by prefix.
+func appendDigitRuns(out [][][2]byte, prefix [][2]byte, a, b string) [][][2]byte {
+	with := func(lo, hi byte) [][2]byte {
+		runs := make([][2]byte, len(prefix), len(prefix)+len(a))
+		copy(runs, prefix)
+		return append(runs, [2]byte{lo, hi})
+	}
+	if a == "" {
+		return append(out, prefix)
+	}
+	if a[0] == b[0] {
+		return appendDigitRuns(out, with(a[0], a[0]), a[1:], b[1:])
+	}
+
+	// Split into a[0] followed by anything from a[1:] up, b[0] followed
+	// by anything up to b[1:], and the digits in between followed by
+	// anything at all.
+	zeros := strings.Repeat("0", len(a)-1)
+	nines := strings.Repeat("9", len(a)-1)
+	lo, hi := a[0], b[0]
+	if a[1:] != zeros {
+		out = appendDigitRuns(out, with(lo, lo), a[1:], nines)
+		lo++
+	}
+	var upper [][][2]byte
+	if b[1:] != nines {
+		upper = appendDigitRuns(nil, with(hi, hi), zeros, b[1:])
+		hi--
+	}
+	if lo <= hi {
+		runs := with(lo, hi)
+		for range zeros {
+			runs = append(runs, [2]byte{'0', '9'})
+		}
+		out = append(out, runs)
+	}
+	return append(out, upper...)
+}
+
+// parseInteger accepts an optional '-' followed by decimal digits.
+func parseInteger(s string) (int64, bool) {
+	digits := strings.TrimPrefix(s, "-")
+	if digits == "" {
+		return 0, false
+	}
+	for _, ch := range digits {
+		if ch < '0' || ch > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	return n, err == nil
+}
+
+// product appends each of alts to each of out.
+func (p *dialectParser) product(out, alts []fragment, offset uint) []fragment {
+	if p.max != 0 && uint64(len(out))*uint64(len(alts)) > uint64(p.max) {
+		p.fail(guts.LimitError, offset, "pattern expands to more than the limit of %d patterns", p.max)
+		return out
+	}
+	next := make([]fragment, 0, len(out)*len(alts))
+	for _, a := range out {
+		for _, b := range alts {
+			next = append(next, a.concat(b))
+		}
+	}
+	return next
+}
+
+// union combines the expansions of a pattern.  Unlike Or, it does not look
+// for operands subsumed by others, which would take time quadratic in the
+// number of expansions; it only drops duplicates and merges neighbours.
+func union(globs []*Glob) *Glob {
+	seen := make(map[string]struct{}, len(globs))
+	kept := make([]*Glob, 0, len(globs))
+	for _, g := range globs {
+		if _, found := seen[g.Pattern()]; found {
+			continue
+		}
+		seen[g.Pattern()] = struct{}{}
+		kept = append(kept, g)
+	}
+	kept = mergeAdjacent(kept)
+	if len(kept) == 1 {
+		return kept[0]
+	}
+	return &Glob{op: orOp, operands: kept, prefix: sharedPrefix(kept)}
+}
@@ -0,0 +1,391 @@
+package glob
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"strconv"
+	"testing"
+)
+
+func TestDialect_EditorConfig(t *testing.T) {
+	type testRow struct {
+		Pattern string
+		String  string
+		Matches []string
+		Rejects []string
+	}
+
+	testData := [...]testRow{
+		{
+			Pattern: "*.js",
+			String:  "**/*.js",
+			Matches: []string{"a.js", "lib/a.js", "lib/x/a.js"},
+			Rejects: []string{"a.jsx", "a.js/b"},
+		},
+		{
+			Pattern: "lib/*.js",
+			String:  "lib/*.js",
+			Matches: []string{"lib/a.js"},
+			Rejects: []string{"x/lib/a.js", "lib/x/a.js"},
+		},
+		{
+			Pattern: "/Makefile",
+			String:  "Makefile",
+			Matches: []string{"Makefile"},
+			Rejects: []string{"src/Makefile"},
+		},
+		{
+			Pattern: "a/**/z.c",
+			Matches: []string{"a/z.c", "a/b/z.c", "a/b/c/z.c"},
+			Rejects: []string{"b/z.c"},
+		},
+		{
+			Pattern: "{a,b}.c",
+			String:  "**/[ab].c",
+			Matches: []string{"a.c", "x/b.c"},
+			Rejects: []string{"c.c", "ab.c"},
+		},
+		{
+			Pattern: "*.{js,py}",
+			String:  `Or("**/*.js", "**/*.py")`,
+			Matches: []string{"a.js", "a.py"},
+			Rejects: []string{"a.c", "a.{js,py}"},
+		},
+		{
+			Pattern: "{a,{b,cd}}",
+			Matches: []string{"a", "b", "cd"},
+			Rejects: []string{"c", "{b,cd}"},
+		},
+		{
+			Pattern: "{single}.b",
+			Matches: []string{"{single}.b"},
+			Rejects: []string{"single.b"},
+		},
+		{
+			Pattern: "{,b}.c",
+			Matches: []string{".c", "b.c"},
+			Rejects: []string{"{,b}.c"},
+		},
+		{
+			Pattern: "{a,b.c",
+			Matches: []string{"{a,b.c"},
+			Rejects: []string{"a", "b.c"},
+		},
+		{
+			Pattern: "file{1..3}",
+			String:  "**/file[1-3]",
+			Matches: []string{"file1", "file2", "file3"},
+			Rejects: []string{"file0", "file4", "file01"},
+		},
+		{
+			Pattern: "{3..-1}",
+			Matches: []string{"-1", "0", "3"},
+			Rejects: []string{"-2", "4"},
+		},
+		{
+			Pattern: "{1..a}",
+			Matches: []string{"{1..a}"},
+		},
+		{
+			Pattern: "[!ab]",
+			Matches: []string{"c", "!"},
+			Rejects: []string{"a", "b"},
+		},
+		{
+			Pattern: "[]a]",
+			Matches: []string{"]", "a"},
+			Rejects: []string{"b"},
+		},
+		{
+			Pattern: "[a-c]x",
+			Matches: []string{"ax", "cx"},
+			Rejects: []string{"dx"},
+		},
+		{
+			Pattern: "a[/]b",
+			Matches: []string{"a[/]b"},
+			Rejects: []string{"a/b"},
+		},
+		{
+			Pattern: "a[b",
+			Matches: []string{"a[b"},
+		},
+		{
+			Pattern: `a\*b\{c`,
+			Matches: []string{"a*b{c"},
+			Rejects: []string{"axb{c"},
+		},
+		{
+			Pattern: "a***b",
+			Matches: []string{"ab", "a/x/b"},
+		},
+	}
+
+	for _, row := range testData {
+		t.Run(row.Pattern, func(t *testing.T) {
+			g, err := Compile(row.Pattern, WithDialect(EditorConfig))
+			if err != nil {
+				t.Fatalf("Compile: unexpected error: %v", err)
+			}
+			if row.String != "" {
+				if str := g.String(); str != row.String {
+					t.Errorf("String: expected %q, got %q", row.String, str)
+				}
+			}
+			for _, input := range row.Matches {
+				if !g.Match(input) {
+					t.Errorf("expected %q to match", input)
+				}
+				if !g.Matcher(input).Matches() {
+					t.Errorf("Matcher: expected %q to match", input)
+				}
+			}
+			for _, input := range row.Rejects {
+				if g.Match(input) {
+					t.Errorf("expected %q not to match", input)
+				}
+			}
+		})
+	}
+}
+
+func TestDialect_Errors(t *testing.T) {
+	strict := Dialect{Name: "strict", BangNegation: true, Braces: true}
+
+	type testRow struct {
+		Name    string
+		Pattern string
+		Dialect Dialect
+		Limits  Limits
+		Kind    ErrorKind
+		Offset  uint
+	}
+
+	testData := [...]testRow{
+		{"UnterminatedSet", "ab[!c", strict, Limits{}, UnterminatedSetError, 2},
+		{"UnterminatedEscape", `ab\`, strict, Limits{}, UnterminatedEscapeError, 2},
+		{"InvalidRange", "{a,[z-a]}", EditorConfig, Limits{}, InvalidRangeError, 6},
+		{"Alternatives", "{a,b}{c,d}{e,f}", EditorConfig, Limits{MaxExpansion: 4}, LimitError, 10},
+		{"Range", "x{1..123456789012}", EditorConfig, Limits{MaxExpansion: 10}, LimitError, 1},
+		{"PatternLength", "{a,b,c}", EditorConfig, Limits{MaxPatternLength: 5}, LimitError, 5},
+	}
+
+	for _, row := range testData {
+		t.Run(row.Name, func(t *testing.T) {
+			_, err := Compile(row.Pattern, WithDialect(row.Dialect), WithLimits(row.Limits))
+			serr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("expected *SyntaxError, got %#v", err)
+			}
+			if serr.Kind != row.Kind {
+				t.Errorf("Kind: expected %v, got %v", row.Kind, serr.Kind)
+			}
+			if serr.Pattern != row.Pattern {
+				t.Errorf("Pattern: expected %q, got %q", row.Pattern, serr.Pattern)
+			}
+			if serr.RuneOffset != row.Offset {
+				t.Errorf("RuneOffset: expected %d, got %d", row.Offset, serr.RuneOffset)
+			}
+		})
+	}
+
+	g, err := Compile("{a,b}{c,d}", WithDialect(EditorConfig), WithLimits(Limits{MaxExpansion: 4}))
+	if err != nil {
+		t.Fatalf("Compile: unexpected error: %v", err)
+	}
+	if !g.Match("bd") {
+		t.Errorf("expected %q to match", "bd")
+	}
+}
+
+func TestDialect_Ranges(t *testing.T) {
+	// A range must match exactly the integers it would expand to.
+	for lo := -120; lo <= 120; lo += 7 {
+		for hi := lo; hi <= 1100; hi += 53 {
+			pattern := fmt.Sprintf("{%d..%d}", lo, hi)
+			g, err := Compile(pattern, WithDialect(EditorConfig))
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %v", pattern, err)
+			}
+			for n := -150; n <= 1200; n++ {
+				input := strconv.Itoa(n)
+				if expect, actual := lo <= n && n <= hi, g.Match(input); actual != expect {
+					t.Errorf("%q against %q: expected %v, got %v", input, pattern, expect, actual)
+				}
+			}
+		}
+	}
+
+	// Neither the size of a range nor the extremes of int64 are a
+	// problem.
+	g, err := Compile("{-9223372036854775808..9223372036854775807}", WithDialect(EditorConfig))
+	if err != nil {
+		t.Fatalf("Compile: unexpected error: %v", err)
+	}
+	for _, input := range []string{"-9223372036854775808", "-1", "0", "42", "9223372036854775807"} {
+		if !g.Match(input) {
+			t.Errorf("expected %q to match", input)
+		}
+	}
+	for _, input := range []string{"-9223372036854775809", "9223372036854775808", "-0", "01", "1a"} {
+		if g.Match(input) {
+			t.Errorf("expected %q not to match", input)
+		}
+	}
+}
+
+func TestDialect_PathMatchErrors(t *testing.T) {
+	// The PathMatch dialect must reject exactly the patterns which
+	// path.Match does.
+	check := func(pattern string) {
+		_, expect := path.Match(pattern, "")
+		_, err := Compile(pattern, WithDialect(PathMatch))
+		if (err != nil) != (expect != nil) {
+			t.Errorf("%q: path.Match gives %v, Compile gives %v", pattern, expect, err)
+		}
+	}
+
+	for _, pattern := range []string{
+		"[]a]", "[-a]", "[a-]", "[^]/]a^", "[a-b-c]", "[^]", "[]", "[a--]", "[a", `a\`,
+		"[b-a]", "[^b-a]", `[\-]`, `[\]]`, `[a-\-]`, "[a-b]", "a^-]",
+	} {
+		check(pattern)
+	}
+
+	alphabet := []string{"a", "b", "/", "*", "?", "[", "]", "^", "-", `\`, "é", "!"}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		var pattern string
+		for n := rng.Intn(9); n > 0; n-- {
+			pattern += alphabet[rng.Intn(len(alphabet))]
+		}
+		check(pattern)
+	}
+
+	if g := MustCompile("[b-a]x", WithDialect(PathMatch)); g.Match("ax") || g.Match("bx") {
+		t.Errorf("expected a backwards range to match nothing")
+	}
+}
+
+func TestDialect_Rules(t *testing.T) {
+	rules, err := ParseRules("+ *.{go,c}\n- vendor/**\n", WithDialect(EditorConfig))
+	if err != nil {
+		t.Fatalf("ParseRules: unexpected error: %v", err)
+	}
+	for input, expect := range map[string]Verdict{
+		"a.go":         Included,
+		"src/a.c":      Included,
+		"vendor/a.go":  Excluded,
+		"a.h":          Unmatched,
+		"vendor2/a.go": Included,
+	} {
+		if verdict, _ := rules.Match(input); verdict != expect {
+			t.Errorf("%q: expected %v, got %v", input, expect, verdict)
+		}
+	}
+}
+
+func TestDialect_Presets(t *testing.T) {
+	type testRow struct {
+		Dialect Dialect
+		Pattern string
+		Matches []string
+		Rejects []string
+	}
+
+	testData := [...]testRow{
+		{Bash, "*.go", []string{"a.go"}, []string{".a.go", "x/a.go"}},
+		{Bash, ".*.go", []string{".a.go"}, []string{"a.go"}},
+		{Bash, "a/*", []string{"a/b"}, []string{"a/.b", "a/b/c"}},
+		{Bash, "**/x", []string{"a/x"}, []string{"x", "a/b/x"}},
+		{Bash, "?x", []string{"ax"}, []string{".x", "/x"}},
+		{Bash, "[.a]x", []string{"ax"}, []string{".x"}},
+		{Bash, "{.x,ax}", []string{".x", "ax"}, []string{"bx", "[.a]x"}},
+		{Bash, "a[/]b", []string{"a[/]b"}, []string{"a/b"}},
+		{Bash, "{a,b}*", []string{"a", "bcd"}, []string{"c"}},
+		{Zsh, "a/**/b", []string{"a/b", "a/x/y/b"}, []string{"a/.x/b"}},
+		{Zsh, "a**b", []string{"ab", "axb"}, []string{"a/b"}},
+		{Zsh, "a/**", []string{"a/b", "a/bc"}, []string{"a/b/c"}},
+		{Zsh, "[a-z]", []string{"a", "z"}, []string{"/"}},
+		{Zsh, "[^a]", []string{"b"}, []string{"a", "/"}},
+		{Zsh, "x[.-0]", []string{"x.", "x0"}, []string{"x/"}},
+		{Zsh, "[!.-0]", []string{"a"}, []string{".", "/", "0"}},
+		{POSIX, "*.py", []string{"a.py", "x/a.py", ".a.py"}, []string{"a.pyc"}},
+		{POSIX, "a?b", []string{"axb", "a/b"}, []string{"ab"}},
+		{POSIX, "*/x", []string{"a/x", "a/b/x", "/x"}, []string{"x"}},
+		{POSIX, "[!a]", []string{"b", "/"}, []string{"a"}},
+		{POSIX, "a[b", []string{"a[b"}, nil},
+		{PathMatch, "*.go", []string{"a.go", ".a.go"}, []string{"x/a.go"}},
+		{PathMatch, "a**b", []string{"ab", "axb"}, []string{"a/b"}},
+		{PathMatch, "[^a]", []string{"b"}, []string{"a"}},
+		{PathMatch, "[!a]", []string{"!"}, []string{"b"}},
+		{PathMatch, "{a,b}", []string{"{a,b}"}, []string{"a"}},
+		{Gitignore, "*.o", []string{"a.o", "x/y/a.o", ".a.o"}, []string{"a.oo"}},
+		{Gitignore, "build/", []string{"build/", "x/build/"}, []string{"build"}},
+		{Gitignore, "/a/*.o", []string{"a/b.o"}, []string{"x/a/b.o", "a/b/c.o"}},
+		{Gitignore, "a/**/b", []string{"a/b", "a/x/y/b"}, []string{"ab"}},
+		{Gitignore, "a**", []string{"ab", "x/ab"}, []string{"a/b"}},
+		{Gitignore, "{a,b}", []string{"{a,b}"}, []string{"a"}},
+		{Minimatch, "**/*.js", []string{"a.js", "x/y/a.js"}, []string{".a.js", ".x/a.js"}},
+		{Minimatch, "*.@(js|ts)", []string{"a.js", "a.ts"}, []string{"a.jsx"}},
+		{Minimatch, "a?(b|c)d", []string{"ad", "abd", "acd"}, []string{"abcd"}},
+		{Minimatch, "!(*.js)", []string{"a.ts", "b"}, []string{"a.js", ".a", "x/a"}},
+		{Minimatch, "src/!(vendor)/x", []string{"src/a/x"}, []string{"src/vendor/x", "src/.a/x"}},
+		{Minimatch, "a(b|c)", []string{"a(b|c)"}, nil},
+		{Dialect{Name: "noescape", Escape: NoEscape}, `a\*`, []string{`a\`, `a\b`}, []string{"a*b"}},
+		{Dialect{Name: "caret", Escape: '^'}, "a^*", []string{"a*"}, []string{"ab"}},
+		{Dialect{Name: "hidden", HideDotfiles: true}, "**", []string{"a/b"}, []string{".a", "a/.b"}},
+	}
+
+	for _, row := range testData {
+		t.Run(row.Dialect.Name+"/"+row.Pattern, func(t *testing.T) {
+			g, err := Compile(row.Pattern, WithDialect(row.Dialect))
+			if err != nil {
+				t.Fatalf("Compile: unexpected error: %v", err)
+			}
+			for _, input := range row.Matches {
+				if !g.Match(input) {
+					t.Errorf("expected %q to match %v", input, g)
+				}
+				if !g.Matcher(input).Matches() {
+					t.Errorf("Matcher: expected %q to match %v", input, g)
+				}
+			}
+			for _, input := range row.Rejects {
+				if g.Match(input) {
+					t.Errorf("expected %q not to match %v", input, g)
+				}
+				if g.Matcher(input).Matches() {
+					t.Errorf("Matcher: expected %q not to match %v", input, g)
+				}
+			}
+		})
+	}
+}
+
+func TestDialect_Unsupported(t *testing.T) {
+	for _, pattern := range []string{"*(a)", "+(a)", "*/!(a)", "{!(a),b}", "!(a)!(b)"} {
+		_, err := Compile(pattern, WithDialect(Minimatch))
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("%q: expected *SyntaxError, got %#v", pattern, err)
+			continue
+		}
+		if serr.Kind != UnsupportedSyntaxError {
+			t.Errorf("%q: expected UnsupportedSyntaxError, got %v", pattern, serr.Kind)
+		}
+	}
+}
+
+func TestDialect_String(t *testing.T) {
+	if str := Bash.String(); str != "Bash" {
+		t.Errorf("expected %q, got %q", "Bash", str)
+	}
+	if str := DoubleStarAlone.String(); str != "DoubleStarAlone" {
+		t.Errorf("expected %q, got %q", "DoubleStarAlone", str)
+	}
+	if str := SeparatorMode(9).String(); str != "%!SeparatorMode(9)" {
+		t.Errorf("expected %q, got %q", "%!SeparatorMode(9)", str)
+	}
+}
@@ -0,0 +1,287 @@
+// Package editorconfig parses .editorconfig files and resolves the
+// properties they assign to a file, as described at https://editorconfig.org.
+//
+// Section names are compiled with the glob.EditorConfig dialect and matched
+// against the path of the file relative to the directory which holds the
+// .editorconfig file.  Files are read from the directory of the target file
+// upwards, stopping at one which sets "root = true"; nearer files override
+// farther ones, and later sections override earlier ones.
+package editorconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	glob "github.com/team-spectre/go-glob"
+)
+
+// File is a parsed .editorconfig file.
+type File struct {
+	Root     bool
+	Sections []*Section
+}
+
+// Section is a glob in square brackets and the properties which follow it.
+type Section struct {
+	Name       string
+	Line       uint
+	Properties []Property
+	glob       *glob.Glob
+}
+
+// Property is one "name = value" line.  Name is lower-cased; Value is kept
+// as written, less surrounding whitespace, except that the value of a
+// property defined by the EditorConfig specification is lower-cased too.
+type Property struct {
+	Name  string
+	Value string
+	Line  uint
+}
+
+// knownProperties are the properties whose values are case-insensitive.
+var knownProperties = map[string]bool{
+	"indent_style":             true,
+	"indent_size":              true,
+	"tab_width":                true,
+	"end_of_line":              true,
+	"charset":                  true,
+	"insert_final_newline":     true,
+	"trim_trailing_whitespace": true,
+}
+
+// ParseError is returned by Parse for a malformed line.
+type ParseError struct {
+	Line    uint
+	Text    string
+	Message string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("editorconfig: line %d: %q: %s", err.Line, err.Text, err.Message)
+}
+
+// Parse reads an .editorconfig file.
+func Parse(r io.Reader) (*File, error) {
+	f := &File{}
+	var current *Section
+
+	scanner := bufio.NewScanner(r)
+	var lineno uint
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		fail := func(format string, args ...interface{}) error {
+			return &ParseError{Line: lineno, Text: line, Message: fmt.Sprintf(format, args...)}
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") || len(line) < 3 {
+				return nil, fail("malformed section header")
+			}
+			name := line[1 : len(line)-1]
+			g, err := glob.Compile(name, glob.WithDialect(glob.EditorConfig), glob.WithNormalization(glob.NoNormalization))
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			current = &Section{Name: name, Line: lineno, glob: g}
+			f.Sections = append(f.Sections, current)
+			continue
+		}
+
+		eq := strings.IndexAny(line, "=:")
+		if eq < 0 {
+			return nil, fail("expected \"name = value\"")
+		}
+		prop := Property{
+			Name:  strings.ToLower(strings.TrimSpace(line[:eq])),
+			Value: strings.TrimSpace(line[eq+1:]),
+			Line:  lineno,
+		}
+		if prop.Name == "" {
+			return nil, fail("missing property name")
+		}
+		if knownProperties[prop.Name] {
+			prop.Value = strings.ToLower(prop.Value)
+		}
+
+		if current == nil {
+			// Only "root" is meaningful before the first section.
+			if prop.Name == "root" {
+				f.Root = strings.EqualFold(prop.Value, "true")
+			}
+			continue
+		}
+		current.Properties = append(current.Properties, prop)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ParseString is like Parse, but reads from a string.
+func ParseString(text string) (*File, error) {
+	return Parse(strings.NewReader(text))
+}
+
+// Glob returns the compiled form of the section name.
+func (s *Section) Glob() *glob.Glob {
+	return s.glob
+}
+
+// Match reports whether the section applies to path, which is relative to
+// the directory of the .editorconfig file and uses '/' as its separator.
+func (s *Section) Match(path string) bool {
+	return s.glob.Match(path)
+}
+
+// Apply copies the properties of every section which matches path into
+// props, in order.  A property whose value is "unset" is removed.
+func (f *File) Apply(path string, props map[string]string) {
+	for _, section := range f.Sections {
+		if !section.Match(path) {
+			continue
+		}
+		for _, prop := range section.Properties {
+			if strings.EqualFold(prop.Value, "unset") {
+				delete(props, prop.Name)
+			} else {
+				props[prop.Name] = prop.Value
+			}
+		}
+	}
+}
+
+// Resolver finds and applies the .editorconfig files for a path.  Parsed
+// files are cached, so a Resolver should be discarded when the files may
+// have changed.  A Resolver is safe for concurrent use.
+type Resolver struct {
+	// FileName is the name of the files to look for.  If empty,
+	// ".editorconfig" is used.
+	FileName string
+
+	// ReadFile reads the named file, and returns an error for which
+	// os.IsNotExist is true if there is none.  If nil, ioutil.ReadFile is
+	// used.
+	ReadFile func(name string) ([]byte, error)
+
+	mu    sync.Mutex
+	cache map[string]*File
+}
+
+var defaultResolver Resolver
+
+// Properties resolves the properties of path using the .editorconfig files
+// on disk.  See Resolver.Properties.
+func Properties(path string) (map[string]string, error) {
+	return defaultResolver.Properties(path)
+}
+
+// Properties resolves the properties of the file at path, which is made
+// absolute if it is not already.  As the specification requires,
+// indent_size defaults to "tab" when indent_style is "tab", and tab_width
+// defaults to indent_size.
+func (r *Resolver) Properties(path string) (map[string]string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	type found struct {
+		dir  string
+		file *File
+	}
+	var files []found
+	for dir := filepath.Dir(path); ; {
+		f, err := r.load(filepath.Join(dir, r.fileName()))
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			files = append(files, found{dir, f})
+			if f.Root {
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	props := make(map[string]string)
+	for i := len(files) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(files[i].dir, path)
+		if err != nil {
+			return nil, err
+		}
+		files[i].file.Apply(filepath.ToSlash(rel), props)
+	}
+
+	if props["indent_style"] == "tab" {
+		if _, found := props["indent_size"]; !found {
+			props["indent_size"] = "tab"
+		}
+	}
+	if size, found := props["indent_size"]; found && size != "tab" {
+		if _, found := props["tab_width"]; !found {
+			props["tab_width"] = size
+		}
+	}
+	if width, found := props["tab_width"]; found && props["indent_size"] == "tab" {
+		props["indent_size"] = width
+	}
+	return props, nil
+}
+
+func (r *Resolver) fileName() string {
+	if r.FileName == "" {
+		return ".editorconfig"
+	}
+	return r.FileName
+}
+
+// load returns the parsed file, or nil if it does not exist.
+func (r *Resolver) load(name string) (*File, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, found := r.cache[name]; found {
+		return f, nil
+	}
+
+	readFile := r.ReadFile
+	if readFile == nil {
+		readFile = ioutil.ReadFile
+	}
+	data, err := readFile(name)
+	var f *File
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		f, err = ParseString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if r.cache == nil {
+		r.cache = make(map[string]*File)
+	}
+	r.cache[name] = f
+	return f, nil
+}
+
+var _ error = (*ParseError)(nil)
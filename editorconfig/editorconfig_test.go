@@ -0,0 +1,177 @@
+package editorconfig
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+var files = map[string]string{
+	"/.editorconfig": `
+[*]
+charset = latin1
+`,
+	"/repo/.editorconfig": `
+# Top-most file.
+root = true
+
+[*]
+indent_style = space
+indent_size = 4
+end_of_line = lf
+
+[*.{go,mod}]
+indent_style = tab
+
+[Makefile]
+indent_style = tab
+
+[docs/**.md]
+trim_trailing_whitespace = false
+`,
+	"/repo/src/.editorconfig": `
+[*.go]
+tab_width = 8
+
+[gen/*.go]
+end_of_line = unset
+`,
+}
+
+func readFile(name string) ([]byte, error) {
+	text, found := files[name]
+	if !found {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return []byte(text), nil
+}
+
+func TestResolver(t *testing.T) {
+	r := &Resolver{ReadFile: readFile}
+
+	type testRow struct {
+		Path   string
+		Expect map[string]string
+	}
+
+	testData := [...]testRow{
+		{"/repo/README", map[string]string{
+			"indent_style": "space",
+			"indent_size":  "4",
+			"tab_width":    "4",
+			"end_of_line":  "lf",
+		}},
+		{"/repo/main.go", map[string]string{
+			"indent_style": "tab",
+			"indent_size":  "4",
+			"tab_width":    "4",
+			"end_of_line":  "lf",
+		}},
+		{"/repo/src/lib/a.go", map[string]string{
+			"indent_style": "tab",
+			"indent_size":  "4",
+			"tab_width":    "8",
+			"end_of_line":  "lf",
+		}},
+		{"/repo/src/gen/a.go", map[string]string{
+			"indent_style": "tab",
+			"indent_size":  "4",
+			"tab_width":    "8",
+		}},
+		{"/repo/docs/a/b.md", map[string]string{
+			"indent_style":             "space",
+			"indent_size":              "4",
+			"tab_width":                "4",
+			"end_of_line":              "lf",
+			"trim_trailing_whitespace": "false",
+		}},
+		{"/other/x.txt", map[string]string{
+			"charset": "latin1",
+		}},
+	}
+
+	for _, row := range testData {
+		t.Run(row.Path, func(t *testing.T) {
+			props, err := r.Properties(row.Path)
+			if err != nil {
+				t.Fatalf("Properties: unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(props, row.Expect) {
+				t.Errorf("expected %v, got %v", row.Expect, props)
+			}
+		})
+	}
+}
+
+func TestIndentDefaults(t *testing.T) {
+	r := &Resolver{ReadFile: func(name string) ([]byte, error) {
+		if name == "/.editorconfig" {
+			return []byte("root = true\n[*]\nindent_style = tab\n"), nil
+		}
+		return readFile(name)
+	}}
+	props, err := r.Properties("/a.c")
+	if err != nil {
+		t.Fatalf("Properties: unexpected error: %v", err)
+	}
+	if props["indent_size"] != "tab" {
+		t.Errorf("indent_size: expected %q, got %q", "tab", props["indent_size"])
+	}
+}
+
+func TestParse_CaseInsensitiveValues(t *testing.T) {
+	r := &Resolver{ReadFile: func(name string) ([]byte, error) {
+		if name == "/.editorconfig" {
+			return []byte("root = true\n[*]\nindent_style = Tab\nTab_Width = 8\nEnd_of_Line = CRLF\nx-custom = KeepMe\n"), nil
+		}
+		return readFile(name)
+	}}
+	props, err := r.Properties("/a.c")
+	if err != nil {
+		t.Fatalf("Properties: unexpected error: %v", err)
+	}
+	expect := map[string]string{
+		"indent_style": "tab",
+		"indent_size":  "8",
+		"tab_width":    "8",
+		"end_of_line":  "crlf",
+		"x-custom":     "KeepMe",
+	}
+	if !reflect.DeepEqual(props, expect) {
+		t.Errorf("expected %v, got %v", expect, props)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	testData := []string{
+		"[*.c\n",
+		"[*]\nindent_style\n",
+		"[*]\n= tab\n",
+		"[{a,[z-a]}]\n",
+	}
+	for _, text := range testData {
+		if _, err := ParseString(text); err == nil {
+			t.Errorf("%q: expected an error", text)
+		} else if _, ok := err.(*ParseError); !ok {
+			t.Errorf("%q: expected *ParseError, got %T", text, err)
+		}
+	}
+}
+
+func TestParse_LargeRange(t *testing.T) {
+	f, err := ParseString("[file{0..200000}.txt]\nindent_style = tab\n")
+	if err != nil {
+		t.Fatalf("Parse: unexpected error: %v", err)
+	}
+	g := f.Sections[0].Glob()
+	for _, name := range []string{"file0.txt", "file1999.txt", "file200000.txt"} {
+		if !g.Match(name) {
+			t.Errorf("expected %q to match", name)
+		}
+	}
+	for _, name := range []string{"file200001.txt", "file007.txt", "file-1.txt"} {
+		if g.Match(name) {
+			t.Errorf("expected %q not to match", name)
+		}
+	}
+}
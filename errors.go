@@ -0,0 +1,101 @@
+package glob
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// ErrorKind classifies a SyntaxError.
+type ErrorKind byte
+
+const (
+	// UnexpectedRuneError indicates a rune which is not valid at its
+	// position, such as an unmatched ']' or a third consecutive '*'.
+	UnexpectedRuneError ErrorKind = ErrorKind(guts.UnexpectedRuneError)
+
+	// UnsupportedSyntaxError indicates syntax which is reserved but not
+	// implemented.
+	UnsupportedSyntaxError ErrorKind = ErrorKind(guts.UnsupportedSyntaxError)
+
+	// InvalidEscapeError indicates a malformed backslash escape.
+	InvalidEscapeError ErrorKind = ErrorKind(guts.InvalidEscapeError)
+
+	// InvalidRangeError indicates a character range whose low end is
+	// greater than its high end, such as "[z-a]".
+	InvalidRangeError ErrorKind = ErrorKind(guts.InvalidRangeError)
+
+	// UnterminatedSetError indicates a '[' without a matching ']'.
+	UnterminatedSetError ErrorKind = ErrorKind(guts.UnterminatedSetError)
+
+	// UnterminatedEscapeError indicates a backslash escape which was cut
+	// short by the end of the pattern.
+	UnterminatedEscapeError ErrorKind = ErrorKind(guts.UnterminatedEscapeError)
+
+	// LimitError indicates a pattern which exceeds one of the Limits
+	// given to Compile.
+	LimitError ErrorKind = ErrorKind(guts.LimitError)
+)
+
+func (x ErrorKind) String() string {
+	return guts.ErrorKind(x).String()
+}
+
+func (x ErrorKind) GoString() string {
+	return guts.ErrorKind(x).GoString()
+}
+
+// SyntaxError is returned by Compile when a pattern is malformed.
+//
+// Pattern holds the pattern after Unicode normalization, and the offsets
+// are relative to that string.
+type SyntaxError struct {
+	Kind       ErrorKind
+	Pattern    string
+	Message    string
+	RuneOffset uint
+	ByteOffset uint
+	what       string
+}
+
+func newSyntaxError(err *guts.ParseError) *SyntaxError {
+	return &SyntaxError{
+		Kind:       ErrorKind(err.Kind),
+		Pattern:    err.Input,
+		Message:    err.Message,
+		RuneOffset: err.RuneOffset,
+		ByteOffset: err.ByteOffset,
+		what:       err.Context,
+	}
+}
+
+func (err *SyntaxError) Error() string {
+	what := err.what
+	if what == "" {
+		what = "glob pattern"
+	}
+	return fmt.Sprintf("failed to parse %s: %q: %s", what, err.Pattern, err.Message)
+}
+
+// Caret renders the pattern on one line and a '^' pointing at the offending
+// rune on the next, suitable for display in a fixed-width font.
+func (err *SyntaxError) Caret() string {
+	var buf strings.Builder
+	buf.WriteString(err.Pattern)
+	buf.WriteByte('\n')
+	for _, ch := range err.Pattern[:err.ByteOffset] {
+		// Preserve tabs so that the caret lines up.
+		if ch == '\t' {
+			buf.WriteByte('\t')
+		} else {
+			buf.WriteByte(' ')
+		}
+	}
+	buf.WriteByte('^')
+	return buf.String()
+}
+
+var _ error = (*SyntaxError)(nil)
+var _ fmt.Stringer = ErrorKind(0)
+var _ fmt.GoStringer = ErrorKind(0)
@@ -0,0 +1,195 @@
+package glob
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// ErrInfinite is returned by Expand when a pattern matches infinitely many
+// strings, i.e. it contains "*" or "**".
+var ErrInfinite = errors.New("glob: pattern matches infinitely many strings")
+
+// ErrExpandLimit is returned by Expand when a pattern matches more strings
+// than the limit allows.
+var ErrExpandLimit = errors.New("glob: pattern matches more strings than the limit")
+
+// Expand lists every string matched by the pattern, sorted and without
+// duplicates.  A limit of zero or less means no limit; otherwise, if the
+// pattern could match more than limit strings, Expand returns
+// ErrExpandLimit without doing the work.  Patterns containing "*" or "**"
+// return ErrInfinite.
+//
+// Note that "?" and negated sets each match over a million runes, so they
+// rarely fit within a reasonable limit.
+//
+// For a Glob built by Or, the expansions of the operands are merged.  For
+// And, the first operand which can be expanded is, and its strings are
+// filtered by the other operands.  Not always returns ErrInfinite.
+func (g *Glob) Expand(limit int) ([]string, error) {
+	out, err := g.expand(limit)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(out)
+	return dedupSorted(out), nil
+}
+
+func (g *Glob) expand(limit int) ([]string, error) {
+	switch g.op {
+	case orOp:
+		var out []string
+		for _, operand := range g.operands {
+			strs, err := operand.expand(limit)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, strs...)
+			if limit > 0 && len(out) > limit {
+				return nil, ErrExpandLimit
+			}
+		}
+		return out, nil
+
+	case andOp:
+		err := ErrInfinite
+		for _, operand := range g.operands {
+			var strs []string
+			strs, err = operand.expand(limit)
+			if err == ErrInfinite {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			out := strs[:0]
+			for _, str := range strs {
+				if g.Match(str) {
+					out = append(out, str)
+				}
+			}
+			return out, nil
+		}
+		return nil, err
+
+	case notOp:
+		return nil, ErrInfinite
+	}
+
+	positions, err := expandPositions(&g.impl)
+	if err != nil {
+		return nil, err
+	}
+
+	total := uint64(1)
+	for _, ranges := range positions {
+		total = mulSaturating(total, countRunes(ranges))
+		if total == 0 {
+			return nil, nil
+		}
+		if limit > 0 && total > uint64(limit) {
+			return nil, ErrExpandLimit
+		}
+	}
+
+	// Count through every combination like an odometer, with the last
+	// position turning fastest.
+	capacity := total
+	if capacity > 1024 {
+		capacity = 1024
+	}
+	out := make([]string, 0, capacity)
+	runes := make([]rune, len(positions))
+	index := make([]int, len(positions))
+	for i, ranges := range positions {
+		runes[i] = ranges[0].Lo
+	}
+	for {
+		str := guts.EncodeRawRunes(runes)
+		if g.Match(str) {
+			out = append(out, str)
+		}
+
+		i := len(positions) - 1
+		for ; i >= 0; i-- {
+			ranges := positions[i]
+			if runes[i] < ranges[index[i]].Hi {
+				runes[i]++
+				break
+			}
+			if index[i]+1 < len(ranges) {
+				index[i]++
+				runes[i] = ranges[index[i]].Lo
+				break
+			}
+			index[i] = 0
+			runes[i] = ranges[0].Lo
+		}
+		if i < 0 {
+			return out, nil
+		}
+	}
+}
+
+// expandPositions returns, for each rune of a matching string, the ranges of
+// runes which can appear there.
+func expandPositions(g *guts.Glob) ([]guts.SortedLoHi, error) {
+	var out []guts.SortedLoHi
+	for i := range g.Segments {
+		seg := &g.Segments[i]
+		switch seg.Type {
+		case guts.LiteralSegment:
+			for _, ch := range seg.Literal.Runes {
+				out = append(out, guts.SortedLoHi{{Lo: ch, Hi: ch}})
+			}
+		case guts.RuneMatchSegment:
+			out = append(out, possibleRunes(guts.Ranges(seg.Matcher)))
+		case guts.QuestionSegment:
+			out = append(out, possibleRunes(guts.Ranges(guts.Is('/').Not())))
+		default:
+			return nil, ErrInfinite
+		}
+	}
+	return out, nil
+}
+
+// possibleRunes removes the surrogates which can never appear in decoded
+// input, keeping those which stand for raw bytes.
+func possibleRunes(ranges guts.SortedLoHi) guts.SortedLoHi {
+	impossible := guts.Union(
+		guts.Range(0xd800, guts.RawByteLo-1),
+		guts.Range(guts.RawByteHi+1, 0xdfff))
+	var out guts.SortedLoHi
+	for _, r := range ranges {
+		out = append(out, guts.Ranges(guts.Subtract(guts.Range(r.Lo, r.Hi), impossible))...)
+	}
+	return out
+}
+
+func countRunes(ranges guts.SortedLoHi) uint64 {
+	var n uint64
+	for _, r := range ranges {
+		n += uint64(r.Hi-r.Lo) + 1
+	}
+	return n
+}
+
+// mulSaturating multiplies a and b, saturating rather than overflowing.
+func mulSaturating(a, b uint64) uint64 {
+	const max = 1 << 62
+	if a != 0 && b > max/a {
+		return max
+	}
+	return a * b
+}
+
+func dedupSorted(strs []string) []string {
+	out := strs[:0]
+	for i, str := range strs {
+		if i == 0 || str != strs[i-1] {
+			out = append(out, str)
+		}
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package glob
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGlob_Expand(t *testing.T) {
+	type testRow struct {
+		Glob     *Glob
+		Limit    int
+		Expected []string
+		Err      error
+	}
+
+	testData := [...]testRow{
+		{MustCompile(""), 0, []string{""}, nil},
+		{MustCompile("abc"), 0, []string{"abc"}, nil},
+		{MustCompile("file[0-3].[ch]"), 0, []string{
+			"file0.c", "file0.h", "file1.c", "file1.h",
+			"file2.c", "file2.h", "file3.c", "file3.h",
+		}, nil},
+		{MustCompile("[ba][dc]"), 4, []string{"ac", "ad", "bc", "bd"}, nil},
+		{MustCompile("[ba][dc]"), 3, nil, ErrExpandLimit},
+		{MustCompile("x?"), 100, nil, ErrExpandLimit},
+		{MustCompile("*.go"), 0, nil, ErrInfinite},
+		{MustCompile("a/**"), 0, nil, ErrInfinite},
+		{Or(MustCompile("[ab]"), MustCompile("[bc]x")), 0, []string{"a", "b", "bx", "cx"}, nil},
+		{And(MustCompile("*"), MustCompile("[a-c]"), Not(MustCompile("b"))), 0, []string{"a", "c"}, nil},
+		{Not(MustCompile("a")), 0, nil, ErrInfinite},
+	}
+
+	for _, row := range testData {
+		t.Run(row.Glob.String(), func(t *testing.T) {
+			actual, err := row.Glob.Expand(row.Limit)
+			if err != row.Err {
+				t.Fatalf("expected error %v, got %v", row.Err, err)
+			}
+			if !reflect.DeepEqual(actual, row.Expected) {
+				t.Errorf("expected %q, got %q", row.Expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package glob
+
+import (
+	"fmt"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// Failure explains why a Matcher rejected its input, in the manner of a
+// parser's "expected X at column N".
+//
+// Matched is the longest prefix of the input which some attempt matched, and
+// RuneOffset and ByteOffset give its length.  Segment is the segment which
+// failed at that point, or nil if the whole pattern matched but input
+// remained.  As with SyntaxError, offsets are relative to the normalized
+// input.
+type Failure struct {
+	Matched    string
+	RuneOffset uint
+	ByteOffset uint
+	Segment    *Segment
+	Reason     RejectReason
+	Message    string
+}
+
+// Failure explains why the input does not match.  It returns nil if the
+// input does match, or if the Matcher's step limit or context ran out
+// before an explanation was found.
+//
+// The explanation is computed afresh on each call, so the Matcher does not
+// need to have been run first.  For a Glob built by Or, the explanation is
+// that of the operand which got furthest; for And, that of the first operand
+// which does not match; and for Not, there is none.
+func (m *Matcher) Failure() *Failure {
+	if m.x != nil {
+		return m.x.failure()
+	}
+	f, ok := m.impl.Explain(m.g)
+	if !ok {
+		return nil
+	}
+
+	input := &m.impl.Input
+	out := &Failure{
+		Matched:    input.Substring(0, f.InputI),
+		RuneOffset: f.InputI,
+		ByteOffset: input.Map[f.InputI],
+		Reason:     RejectReason(f.Reason),
+	}
+
+	var found string
+	if f.InputI < uint(len(input.Runes)) {
+		found = fmt.Sprintf("%q", input.Runes[f.InputI])
+	} else {
+		found = "end of input"
+	}
+
+	if f.SegmentI >= uint(len(m.g.Segments)) {
+		out.Message = fmt.Sprintf("expected end of input, found %q",
+			input.Substring(f.InputI, uint(len(input.Runes))))
+		return out
+	}
+
+	seg := Segment{g: m.g, i: f.SegmentI}
+	out.Segment = &seg
+	if f.Reason == guts.DotfileReason {
+		out.Message = "expected a pattern beginning with '.', found hidden path component"
+		return out
+	}
+	out.Message = fmt.Sprintf("expected %s, found %s", expectation(seg.impl(), f.InputI-f.InputP), found)
+	return out
+}
+
+// expectation describes what seg would have accepted next, given that k
+// runes of it already matched.
+func expectation(seg *guts.Segment, k uint) string {
+	switch seg.Type {
+	case guts.LiteralSegment:
+		return fmt.Sprintf("%q", guts.EncodeRawRunes(seg.Literal.Runes[k:]))
+	case guts.RuneMatchSegment:
+		return "one of " + string(guts.AppendRuneMatcher(nil, seg.Matcher))
+	case guts.QuestionSegment:
+		return "any rune except '/'"
+	default:
+		return "end of input"
+	}
+}
+
+func (f *Failure) String() string {
+	return fmt.Sprintf("%s at offset %d", f.Message, f.RuneOffset)
+}
+
+var _ fmt.Stringer = (*Failure)(nil)
@@ -9,18 +9,41 @@ import (
 // Glob represents a compiled glob pattern, ready to match path names.
 type Glob struct {
 	impl guts.Glob
+
+	// Set by Or, And and Not.
+	op       combinator
+	operands []*Glob
+	prefix   []rune
 }
 
-func Compile(input string) (*Glob, error) {
+// Compile parses a glob pattern.  If the pattern is malformed, the error is
+// a *SyntaxError.
+func Compile(input string, opts ...Option) (*Glob, error) {
+	o := buildOptions(opts)
+	if !o.dialect.isNative() {
+		return compileDialect(input, o)
+	}
 	g := new(Glob)
-	if err := g.impl.Compile(input); err != nil {
-		return nil, fmt.Errorf("failed to parse glob pattern: %q: %v", input, err)
+	if err := g.impl.CompileWith(input, o.impl); err != nil {
+		return nil, newSyntaxError(err.(*guts.ParseError))
 	}
 	return g, nil
 }
 
-func MustCompile(input string) *Glob {
-	compiled, err := Compile(input)
+// QuoteMeta returns a pattern which matches exactly the string s, escaping
+// every character that would otherwise have a special meaning.
+func QuoteMeta(s string) string {
+	runes := make([]rune, 0, len(s))
+	for i := 0; i < len(s); {
+		ch, size := guts.DecodeRawRune(s[i:])
+		runes = guts.SafeAppendRune(runes, ch)
+		i += size
+	}
+	return string(runes)
+}
+
+func MustCompile(input string, opts ...Option) *Glob {
+	compiled, err := Compile(input, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -28,25 +51,111 @@ func MustCompile(input string) *Glob {
 }
 
 func (g *Glob) Matcher(input string) *Matcher {
+	if g.op != leafOp {
+		return g.combinedMatcher(input, func(operand *Glob) *Matcher {
+			return operand.Matcher(input)
+		})
+	}
 	m := new(Matcher)
-	m.g = &g.impl
+	m.init(g)
 	g.impl.Matcher(&m.impl, input)
+	m.impl.Budget = m.budgetPtr()
 	return m
 }
 
+// Match reports whether the entire input matches the pattern.  It is
+// equivalent to g.Matcher(input).Matches(), but for ASCII input and patterns
+// with at most one star it runs without allocating.
+func (g *Glob) Match(input string) bool {
+	if g.op != leafOp {
+		return g.matchCombined(func(operand *Glob) bool {
+			return operand.Match(input)
+		})
+	}
+	if g.impl.Options.Limits.MaxSteps == 0 {
+		if matched, ok := g.impl.MatchFast(input); ok {
+			return matched
+		}
+	}
+	return g.Matcher(input).Matches()
+}
+
+// BytesMatcher returns a Matcher for a raw byte string, such as a Linux file
+// name which need not be valid UTF-8.  The input is not normalized, so
+// Capture.Input round-trips the original bytes exactly.  Each byte of an
+// invalid UTF-8 sequence is a distinct rune in the range U+DC80..U+DCFF:
+// it is matched by "?", "*" and negated sets, and can be matched exactly
+// with an escape, e.g. "\udce9" matches the Latin-1 byte 0xE9.
+//
+// For fully byte-exact semantics, compile the pattern with NoNormalization.
+func (g *Glob) BytesMatcher(input []byte) *Matcher {
+	if g.op != leafOp {
+		return g.combinedMatcher(string(input), func(operand *Glob) *Matcher {
+			return operand.BytesMatcher(input)
+		})
+	}
+	m := new(Matcher)
+	m.init(g)
+	g.impl.BytesMatcher(&m.impl, string(input))
+	m.impl.Budget = m.budgetPtr()
+	return m
+}
+
+// MatchBytes reports whether the raw byte string matches the pattern.  See
+// BytesMatcher for details.
+func (g *Glob) MatchBytes(input []byte) bool {
+	return g.BytesMatcher(input).Matches()
+}
+
+// Pattern returns the pattern after normalization, or "" for a Glob built by
+// Or, And or Not.
 func (g *Glob) Pattern() string {
+	if g.op != leafOp {
+		return ""
+	}
 	return g.impl.Pattern.String
 }
 
+// PatternSubstring returns runes [i, j) of Pattern, or "" for a Glob built by
+// Or, And or Not.
 func (g *Glob) PatternSubstring(i, j uint) string {
+	if g.op != leafOp {
+		return ""
+	}
 	return g.impl.Pattern.Substring(i, j)
 }
 
+// IsLiteral returns true iff the pattern matches exactly one string, i.e. it
+// contains no wildcards and no character sets of more than one rune.
+func (g *Glob) IsLiteral() bool {
+	if g.op != leafOp {
+		return false
+	}
+	_, ok := g.impl.LiteralValue()
+	return ok
+}
+
+// LiteralValue returns the only string matched by the pattern, or "" if
+// IsLiteral returns false.
+func (g *Glob) LiteralValue() string {
+	if g.op != leafOp {
+		return ""
+	}
+	str, _ := g.impl.LiteralValue()
+	return str
+}
+
 func (g *Glob) String() string {
+	if g.op != leafOp {
+		return g.combinedString(false)
+	}
 	return g.Pattern()
 }
 
 func (g *Glob) GoString() string {
+	if g.op != leafOp {
+		return g.combinedString(true)
+	}
 	return fmt.Sprintf("glob.MustCompile(%q)", g.Pattern())
 }
 
@@ -54,31 +163,90 @@ var _ fmt.Stringer = (*Glob)(nil)
 var _ fmt.GoStringer = (*Glob)(nil)
 
 type Matcher struct {
-	impl guts.Matcher
-	g    *guts.Glob
+	impl   guts.Matcher
+	budget guts.Budget
+	c      Capture
+	g      *guts.Glob
+	x      *combination
+}
+
+func (m *Matcher) init(g *Glob) {
+	m.g = &g.impl
+	m.budget = guts.Budget{MaxSteps: g.impl.Options.Limits.MaxSteps}
+}
+
+func (m *Matcher) budgetPtr() *guts.Budget {
+	if m.budget.MaxSteps == 0 && m.budget.Context == nil {
+		return nil
+	}
+	return &m.budget
+}
+
+// Reset prepares the Matcher to match a new input against the same Glob,
+// reusing the memory allocated for the previous input.  A Matcher returned
+// by BytesMatcher continues to treat its input as raw bytes.
+func (m *Matcher) Reset(input string) {
+	if m.x != nil {
+		m.x.reset(input, func(sub *Matcher) { sub.Reset(input) })
+		return
+	}
+	m.impl.Reset(m.g, input)
+}
+
+// ResetBytes is like Reset, but switches the Matcher to raw byte mode as if
+// it had been returned by BytesMatcher.
+func (m *Matcher) ResetBytes(input []byte) {
+	if m.x != nil {
+		m.x.reset(string(input), func(sub *Matcher) { sub.ResetBytes(input) })
+		return
+	}
+	m.impl.Form = guts.NoNorm
+	m.impl.Reset(m.g, string(input))
 }
 
 func (m *Matcher) Input() string {
+	if m.x != nil {
+		return m.x.subs[0].Input()
+	}
 	return m.impl.Input.String
 }
 
 func (m *Matcher) InputSubstring(i, j uint) string {
+	if m.x != nil {
+		return m.x.subs[0].InputSubstring(i, j)
+	}
 	return m.impl.Input.Substring(i, j)
 }
 
 func (m *Matcher) HasNext() bool {
+	if m.x != nil {
+		m.x.decide()
+		return m.x.pick != nil && m.x.pick.HasNext()
+	}
 	return m.impl.HasNext(m.g)
 }
 
+// Capture describes the input matched by the most recent successful call to
+// HasNext.  The same *Capture is returned every time, and its contents are
+// updated by each call to HasNext or Reset.
 func (m *Matcher) Capture() *Capture {
-	c := new(Capture)
-	c.impl = m.impl.Capture()
-	c.m = &m.impl
-	c.g = m.g
-	return c
+	if m.x != nil {
+		if m.x.pick == nil {
+			panic(fmt.Errorf("call to Capture() after HasNext() return false"))
+		}
+		return m.x.pick.Capture()
+	}
+	m.c.impl = m.impl.Capture()
+	m.c.m = &m.impl
+	m.c.g = m.g
+	return &m.c
 }
 
 func (m *Matcher) OK() bool {
+	if m.x != nil {
+		m.x.decide()
+		return m.x.ok && (m.x.pick == nil || m.x.pick.OK())
+	}
 	return m.impl.Valid
 }
 
@@ -1,6 +1,10 @@
 package glob
 
 import (
+	"context"
+	"path"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -85,11 +89,744 @@ func TestGlob_Match(t *testing.T) {
 				if row.G.Matcher(input).Matches() {
 					t.Errorf("Match %q: unexpected acceptance", input)
 				}
+				if row.G.Match(input) {
+					t.Errorf("Match %q: unexpected acceptance by fast path", input)
+				}
 			}
 			for _, input := range row.ExpectAccept {
 				if !row.G.Matcher(input).Matches() {
 					t.Errorf("Match %q: unexpected rejection", input)
 				}
+				if !row.G.Match(input) {
+					t.Errorf("Match %q: unexpected rejection by fast path", input)
+				}
+			}
+		})
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	type testrow struct {
+		Name          string
+		B             *Builder
+		ExpectPattern string
+		ExpectTypes   []SegmentType
+		ExpectAccept  []string
+		ExpectReject  []string
+	}
+
+	digits := RangeClass('0', '9')
+
+	testdata := []testrow{
+		{
+			Name:          "Escaping",
+			B:             new(Builder).Literal("a[b]*c?/").Star(),
+			ExpectPattern: `a\[b\]\*c\?/*`,
+			ExpectTypes:   []SegmentType{LiteralSegment, StarSegment},
+			ExpectAccept:  []string{"a[b]*c?/", "a[b]*c?/x"},
+			ExpectReject:  []string{"a[b]xc?/", "a[b]*c?/x/y"},
+		},
+		{
+			Name:          "Deep",
+			B:             new(Builder).Literal("src/").DoubleStarSlash().Class(digits).Question().Star().Literal(".go"),
+			ExpectPattern: "src/**/[0-9]?*.go",
+			ExpectTypes: []SegmentType{
+				LiteralSegment,
+				DoubleStarSlashSegment,
+				RuneMatchSegment,
+				QuestionSegment,
+				StarSegment,
+				LiteralSegment,
+			},
+			ExpectAccept: []string{"src/1x.go", "src/a/b/1xyz.go"},
+			ExpectReject: []string{"src/x1.go", "src/1.go"},
+		},
+		{
+			Name:          "DoubleStarThenSlash",
+			B:             new(Builder).DoubleStar().Literal("/x"),
+			ExpectPattern: "**[/]x",
+			ExpectTypes:   []SegmentType{DoubleStarSegment, RuneMatchSegment, LiteralSegment},
+			ExpectAccept:  []string{"/x", "a/b/x"},
+			ExpectReject:  []string{"x"},
+		},
+		{
+			Name:          "MergedStars",
+			B:             new(Builder).Star().Star().DoubleStar().Star(),
+			ExpectPattern: "**",
+			ExpectTypes:   []SegmentType{DoubleStarSegment},
+			ExpectAccept:  []string{"", "a/b"},
+		},
+		{
+			Name:          "MergedDoubleStarSlashes",
+			B:             new(Builder).DoubleStarSlash().DoubleStarSlash().Literal("a/").DoubleStarSlash().DoubleStar(),
+			ExpectPattern: "**/a/**",
+			ExpectTypes:   []SegmentType{DoubleStarSlashSegment, LiteralSegment, DoubleStarSegment},
+			ExpectAccept:  []string{"a/", "x/a/b/c"},
+			ExpectReject:  []string{"a"},
+		},
+	}
+
+	for _, row := range testdata {
+		t.Run(row.Name, func(t *testing.T) {
+			if actual := row.B.Pattern(); actual != row.ExpectPattern {
+				t.Errorf("Pattern: expected %q, got %q", row.ExpectPattern, actual)
+			}
+			g, err := row.B.Build()
+			if err != nil {
+				t.Errorf("Build: unexpected error: %v", err)
+				return
+			}
+			segments := g.Segments()
+			if len(segments) != len(row.ExpectTypes) {
+				t.Errorf("Segments: expected %d, got %d", len(row.ExpectTypes), len(segments))
+			} else {
+				for i, seg := range segments {
+					if seg.Type() != row.ExpectTypes[i] {
+						t.Errorf("Segments[%d]: expected %#v, got %#v", i, row.ExpectTypes[i], seg.Type())
+					}
+				}
+			}
+			for _, input := range row.ExpectReject {
+				if g.Matcher(input).Matches() {
+					t.Errorf("Match %q: unexpected acceptance", input)
+				}
+			}
+			for _, input := range row.ExpectAccept {
+				if !g.Matcher(input).Matches() {
+					t.Errorf("Match %q: unexpected rejection", input)
+				}
+			}
+		})
+	}
+
+	if _, err := new(Builder).Star().DoubleStarSlash().Build(); err == nil {
+		t.Errorf("Build: expected error for Star followed by DoubleStarSlash")
+	}
+}
+
+func TestQuoteMeta(t *testing.T) {
+	inputs := []string{
+		"",
+		"plain/dir",
+		"weird [dir] *name*?",
+		`back\slash {braces} ^caret- -dash`,
+		"tab\there",
+	}
+	for _, input := range inputs {
+		pattern := QuoteMeta(input)
+		g, err := Compile(pattern)
+		if err != nil {
+			t.Errorf("QuoteMeta %q: %q: unexpected error: %v", input, pattern, err)
+			continue
+		}
+		if !g.IsLiteral() {
+			t.Errorf("QuoteMeta %q: %q: expected IsLiteral", input, pattern)
+		}
+		if actual := g.LiteralValue(); actual != input {
+			t.Errorf("QuoteMeta %q: %q: LiteralValue: expected %q, got %q", input, pattern, input, actual)
+		}
+		if !g.Matcher(input).Matches() {
+			t.Errorf("QuoteMeta %q: %q: unexpected rejection", input, pattern)
+		}
+	}
+
+	if g := MustCompile("a[b]c"); !g.IsLiteral() || g.LiteralValue() != "abc" {
+		t.Errorf("a[b]c: expected literal %q, got %v %q", "abc", g.IsLiteral(), g.LiteralValue())
+	}
+	if g := MustCompile("a[bc]"); g.IsLiteral() || g.LiteralValue() != "" {
+		t.Errorf("a[bc]: expected non-literal, got %v %q", g.IsLiteral(), g.LiteralValue())
+	}
+}
+
+func TestCompile_SyntaxError(t *testing.T) {
+	type testrow struct {
+		Name         string
+		Pattern      string
+		ExpectKind   ErrorKind
+		ExpectOffset uint
+		ExpectByte   uint
+		ExpectCaret  string
+	}
+	testdata := []testrow{
+		{
+			Name:         "TripleStar",
+			Pattern:      "foo/***",
+			ExpectKind:   UnexpectedRuneError,
+			ExpectOffset: 4,
+			ExpectByte:   4,
+			ExpectCaret:  "foo/***\n    ^",
+		},
+		{
+			Name:         "UnterminatedSet",
+			Pattern:      "é/[a-z",
+			ExpectKind:   UnterminatedSetError,
+			ExpectOffset: 2,
+			ExpectByte:   3,
+			ExpectCaret:  "é/[a-z\n  ^",
+		},
+		{
+			Name:         "InvalidEscape",
+			Pattern:      "\tx\\q",
+			ExpectKind:   InvalidEscapeError,
+			ExpectOffset: 2,
+			ExpectByte:   2,
+			ExpectCaret:  "\tx\\q\n\t ^",
+		},
+		{
+			Name:         "InvalidRange",
+			Pattern:      "[z-a]",
+			ExpectKind:   InvalidRangeError,
+			ExpectOffset: 3,
+			ExpectByte:   3,
+			ExpectCaret:  "[z-a]\n   ^",
+		},
+	}
+	for _, row := range testdata {
+		t.Run(row.Name, func(t *testing.T) {
+			_, err := Compile(row.Pattern)
+			serr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Errorf("expected *SyntaxError, got %T: %v", err, err)
+				return
+			}
+			if serr.Kind != row.ExpectKind {
+				t.Errorf("Kind: expected %#v, got %#v", row.ExpectKind, serr.Kind)
+			}
+			if serr.RuneOffset != row.ExpectOffset {
+				t.Errorf("RuneOffset: expected %d, got %d", row.ExpectOffset, serr.RuneOffset)
+			}
+			if serr.ByteOffset != row.ExpectByte {
+				t.Errorf("ByteOffset: expected %d, got %d", row.ExpectByte, serr.ByteOffset)
+			}
+			if actual := serr.Caret(); actual != row.ExpectCaret {
+				t.Errorf("Caret: expected %q, got %q", row.ExpectCaret, actual)
+			}
+		})
+	}
+
+	_, err := Compile("]")
+	if expect := "failed to parse glob pattern: \"]\": unexpected ']'"; err == nil || err.Error() != expect {
+		t.Errorf("Error: expected %q, got %v", expect, err)
+	}
+}
+
+func TestCompile_Normalization(t *testing.T) {
+	const (
+		composed   = "café"
+		decomposed = "café"
+		ligature   = "ﬁle"
+		fullWidth  = "１"
+	)
+
+	type testrow struct {
+		Name         string
+		Form         Normalization
+		Pattern      string
+		ExpectAccept []string
+		ExpectReject []string
+	}
+	testdata := []testrow{
+		{
+			Name:         "NFKC",
+			Form:         NFKC,
+			Pattern:      "file[0-9]",
+			ExpectAccept: []string{"file1", ligature + "1", "file" + fullWidth},
+		},
+		{
+			Name:         "NFC",
+			Form:         NFC,
+			Pattern:      composed + "/*",
+			ExpectAccept: []string{composed + "/x", decomposed + "/x"},
+			ExpectReject: []string{"cafe/x"},
+		},
+		{
+			Name:         "NFD",
+			Form:         NFD,
+			Pattern:      decomposed,
+			ExpectAccept: []string{composed, decomposed},
+		},
+		{
+			Name:         "None",
+			Form:         NoNormalization,
+			Pattern:      "file[0-9]",
+			ExpectAccept: []string{"file1"},
+			ExpectReject: []string{ligature + "1", "file" + fullWidth},
+		},
+		{
+			Name:         "NoneComposed",
+			Form:         NoNormalization,
+			Pattern:      composed,
+			ExpectAccept: []string{composed},
+			ExpectReject: []string{decomposed},
+		},
+	}
+	for _, row := range testdata {
+		t.Run(row.Name, func(t *testing.T) {
+			g := MustCompile(row.Pattern, WithNormalization(row.Form))
+			for _, input := range row.ExpectReject {
+				if g.Matcher(input).Matches() {
+					t.Errorf("Match %q: unexpected acceptance", input)
+				}
+			}
+			for _, input := range row.ExpectAccept {
+				if !g.Matcher(input).Matches() {
+					t.Errorf("Match %q: unexpected rejection", input)
+				}
+			}
+		})
+	}
+}
+
+func TestCompile_NormalizationSets(t *testing.T) {
+	const (
+		composed   = "é"
+		decomposed = "é"
+	)
+
+	// Whatever the form, a set or '?' matches an accented letter however
+	// it is encoded, and never the bare letter.
+	for _, form := range []Normalization{NFKC, NFC, NFD, NFKD} {
+		for _, pattern := range []string{"caf[" + composed + "]", "caf[" + decomposed + "]", "caf?", "caf[^e]", "caf[à-ú]"} {
+			g := MustCompile(pattern, WithNormalization(form))
+			for _, input := range []string{"caf" + composed, "caf" + decomposed} {
+				if !g.Match(input) || !g.Matcher(input).Matches() {
+					t.Errorf("%v: %q against %q: unexpected rejection", form, input, pattern)
+				}
+			}
+			for _, input := range []string{"cafe", "caf"} {
+				if input == "cafe" && pattern == "caf?" {
+					continue
+				}
+				if g.Match(input) || g.Matcher(input).Matches() {
+					t.Errorf("%v: %q against %q: unexpected acceptance", form, input, pattern)
+				}
+			}
+		}
+	}
+}
+
+func TestGlob_MatchBytes(t *testing.T) {
+	latin1 := []byte("caf\xe9.txt")
+	other := []byte("caf\xe8.txt")
+
+	g := MustCompile(`caf\udce9.txt`, WithNormalization(NoNormalization))
+	if !g.MatchBytes(latin1) {
+		t.Errorf("MatchBytes %q: unexpected rejection", latin1)
+	}
+	if g.MatchBytes(other) {
+		t.Errorf("MatchBytes %q: unexpected acceptance", other)
+	}
+
+	g = MustCompile(QuoteMeta(string(other)), WithNormalization(NoNormalization))
+	if g.MatchBytes(latin1) || !g.MatchBytes(other) {
+		t.Errorf("QuoteMeta %q: expected exact byte match", other)
+	}
+	if actual := g.LiteralValue(); actual != string(other) {
+		t.Errorf("LiteralValue: expected %q, got %q", other, actual)
+	}
+
+	g = MustCompile("caf?.*")
+	m := g.BytesMatcher(latin1)
+	var captured []string
+	for m.HasNext() {
+		captured = append(captured, m.Capture().Input())
+	}
+	if !m.OK() {
+		t.Errorf("BytesMatcher %q: unexpected rejection", latin1)
+	}
+	expect := []string{"caf", "\xe9", ".", "txt"}
+	if len(captured) != len(expect) {
+		t.Errorf("BytesMatcher %q: expected captures %q, got %q", latin1, expect, captured)
+	} else {
+		for i := range expect {
+			if captured[i] != expect[i] {
+				t.Errorf("BytesMatcher %q: capture %d: expected %q, got %q", latin1, i, expect[i], captured[i])
+			}
+		}
+	}
+}
+
+func TestGlob_Match_FastPath(t *testing.T) {
+	patterns := []string{
+		"",
+		"foo/bar/[0-9][0-9]-?",
+		"*.go",
+		"src/*_test.go",
+		"**",
+		"a/**",
+		"**/x",
+		"a**b",
+		"**/*.go",
+	}
+	inputs := []string{
+		"",
+		"foo/bar/12-x",
+		"main.go",
+		"src/main_test.go",
+		"src/sub/main_test.go",
+		"a/b/c",
+		"a",
+		"x",
+		"a/x",
+		"a/b/x",
+		"aXb",
+		"a/b",
+		"cmd/glob/main.go",
+		"ümlaut.go",
+	}
+	for _, pattern := range patterns {
+		g := MustCompile(pattern)
+		for _, input := range inputs {
+			expect := g.Matcher(input).Matches()
+			if actual := g.Match(input); actual != expect {
+				t.Errorf("Match %q against %q: expected %v, got %v", input, pattern, expect, actual)
+			}
+		}
+	}
+
+	g := MustCompile("src/*_test.go")
+	allocs := testing.AllocsPerRun(100, func() {
+		g.Match("src/some/deeper/path_test.go")
+		g.Match("src/path_test.go")
+	})
+	if allocs != 0 {
+		t.Errorf("Match: expected 0 allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkGlob_Match(b *testing.B) {
+	g := MustCompile("src/*_test.go")
+	for i := 0; i < b.N; i++ {
+		g.Match("src/matcher_test.go")
+	}
+}
+
+func BenchmarkGlob_Matcher(b *testing.B) {
+	g := MustCompile("src/*_test.go")
+	for i := 0; i < b.N; i++ {
+		g.Matcher("src/matcher_test.go").Matches()
+	}
+}
+
+func BenchmarkPath_Match(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		path.Match("src/*_test.go", "src/matcher_test.go")
+	}
+}
+
+func TestMatcher_Reset(t *testing.T) {
+	g := MustCompile("foo/**/[0-9]*.txt")
+	inputs := []string{
+		"foo/1.txt",
+		"foo/bar/x.txt",
+		"foo/bar/baz/22.txt",
+		"foo/bar/baz/22.txt/",
+		"",
+	}
+
+	m := g.Matcher("")
+	pooled := g.AcquireMatcher("")
+	defer pooled.Release()
+	for _, input := range inputs {
+		expect := g.Matcher(input).Matches()
+		m.Reset(input)
+		if actual := m.Matches(); actual != expect {
+			t.Errorf("Reset %q: expected %v, got %v", input, expect, actual)
+		}
+		pooled.Reset(input)
+		if actual := pooled.Matches(); actual != expect {
+			t.Errorf("AcquireMatcher %q: expected %v, got %v", input, expect, actual)
+		}
+	}
+}
+
+func BenchmarkMatcher_Reset(b *testing.B) {
+	g := MustCompile("foo/**/[0-9]*.txt")
+	m := g.Matcher("")
+	for i := 0; i < b.N; i++ {
+		m.Reset("foo/bar/baz/22.txt")
+		m.Matches()
+	}
+}
+
+func BenchmarkMatcher_DeepDoubleStar(b *testing.B) {
+	g := MustCompile("**/a/**/b/**/c/**/*.go")
+	input := strings.Repeat("a/b/", 12) + strings.Repeat("d/", 12) + "main.go"
+	m := g.Matcher("")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Reset(input)
+		m.Matches()
+	}
+}
+
+func BenchmarkMatcher_StarAnchor(b *testing.B) {
+	g := MustCompile("**/*.min.js")
+	input := strings.Repeat("node_modules/package/", 8) + "dist.min.min.js.map/" + strings.Repeat("x", 64) + ".min.js"
+	m := g.Matcher("")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Reset(input)
+		m.Matches()
+	}
+}
+
+func TestSegment_Pattern(t *testing.T) {
+	type testrow struct {
+		Pattern string
+		Expect  []string
+	}
+
+	testdata := []testrow{
+		{"**/", []string{"**/"}},
+		{"**", []string{"**"}},
+		{"*", []string{"*"}},
+		{"[ab]", []string{"[ab]"}},
+		{"[^a]", []string{"[^a]"}},
+		{`\*x`, []string{`\*x`}},
+		{`x\*y`, []string{`x\*y`}},
+		{`a/**/b[c-d]\?*.go`, []string{"a/", "**/", "b", "[c-d]", `\?`, "*", ".go"}},
+		{"**a/b", []string{"**", "a/b"}},
+	}
+
+	for _, row := range testdata {
+		g := MustCompile(row.Pattern)
+		var actual []string
+		end := uint(0)
+		for _, seg := range g.Segments() {
+			actual = append(actual, seg.Pattern())
+			if p, q := seg.PatternLocation(); p != end || q < p {
+				t.Errorf("%q: segment %q at [%d:%d], expected it to start at %d", row.Pattern, seg.Pattern(), p, q, end)
+			}
+			end = seg.PatternEnd()
+		}
+		if !reflect.DeepEqual(actual, row.Expect) {
+			t.Errorf("%q: expected %q, got %q", row.Pattern, row.Expect, actual)
+		}
+		if end != uint(len([]rune(row.Pattern))) {
+			t.Errorf("%q: segments end at %d", row.Pattern, end)
+		}
+	}
+}
+
+func TestMatcher_LiteralAnchor(t *testing.T) {
+	type testrow struct {
+		Pattern string
+		Input   string
+		Options []Option
+		Expect  bool
+	}
+
+	testdata := []testrow{
+		// The literal after a star occurs more than once, or overlaps
+		// itself, so the last occurrence is not always the right one.
+		{"*aa*a", "aaaa", nil, true},
+		{"*aa*a", "aaa", nil, true},
+		{"*aa*a", "aa", nil, false},
+		{"*ab", "abab", nil, true},
+		{"*ab", "ababa", nil, false},
+		{"*a*a*a", "aaa", nil, true},
+		{"*a*a*a", "aa", nil, false},
+		{"*aba*b", "ababab", nil, true},
+		{"*aba*b", "abab", nil, true},
+		{"*aba*b", "abba", nil, false},
+		{"**/ab*ab", "x/abab", nil, true},
+		{"**/ab*ab", "x/aba", nil, false},
+		{"**ab/c", "ab/ab/c", nil, true},
+		{"**ab/c", "ab/abc", nil, false},
+
+		// The prefix and suffix are both present, so the fast reject
+		// must let these through.
+		{"ab*ba", "abba", nil, true},
+		{"ab*b", "abb", nil, true},
+		{"a**a", "a/a", nil, true},
+		{"a*a", "aa", nil, true},
+		{"ﬁ*le", "file", []Option{WithNormalization(NFKC)}, true},
+		{"ﬁ*le", "ﬁle", []Option{WithNormalization(NFKC)}, true},
+
+		// Here the prefix and suffix would have to overlap.
+		{"ab*ba", "aba", nil, false},
+		{"a*a", "a", nil, false},
+	}
+
+	for _, row := range testdata {
+		g := MustCompile(row.Pattern, row.Options...)
+		if actual := g.Matcher(row.Input).Matches(); actual != row.Expect {
+			t.Errorf("Matcher: %q against %q: expected %v, got %v", row.Input, row.Pattern, row.Expect, actual)
+		}
+		if actual := g.Match(row.Input); actual != row.Expect {
+			t.Errorf("Match: %q against %q: expected %v, got %v", row.Input, row.Pattern, row.Expect, actual)
+		}
+	}
+}
+
+func TestCompile_Limits(t *testing.T) {
+	type testrow struct {
+		Name    string
+		Pattern string
+		Limits  Limits
+		Expect  bool
+	}
+	testdata := []testrow{
+		{"NoLimits", "a*b*c*d*e*f", Limits{}, true},
+		{"PatternLength", "abcdef", Limits{MaxPatternLength: 5}, false},
+		{"PatternLengthOK", "abcde", Limits{MaxPatternLength: 5}, true},
+		{"Segments", "a*b*c", Limits{MaxSegments: 4}, false},
+		{"Wildcards", "a*b?c**", Limits{MaxWildcards: 2}, false},
+		{"WildcardsOK", "a*b?c", Limits{MaxWildcards: 2}, true},
+		{"SetRanges", "[acegikm]", Limits{MaxSetRanges: 4}, false},
+	}
+	for _, row := range testdata {
+		t.Run(row.Name, func(t *testing.T) {
+			_, err := Compile(row.Pattern, WithLimits(row.Limits))
+			if row.Expect && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !row.Expect {
+				serr, ok := err.(*SyntaxError)
+				if !ok || serr.Kind != LimitError {
+					t.Errorf("expected *SyntaxError of kind LimitError, got %#v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMatcher_Limits(t *testing.T) {
+	pattern := strings.Repeat("*a", 12) + "*b"
+	input := strings.Repeat("a", 40) + "/b"
+
+	g := MustCompile(pattern, WithLimits(Limits{MaxSteps: 100}))
+	m := g.Matcher(input)
+	if m.Matches() {
+		t.Errorf("Matches: unexpected acceptance")
+	}
+	if m.Err() != ErrStepLimit {
+		t.Errorf("Err: expected %v, got %v", ErrStepLimit, m.Err())
+	}
+
+	m.SetMaxSteps(0)
+	m.Reset(input)
+	if m.Matches() || m.Err() != nil {
+		t.Errorf("Matches: expected clean rejection, got err %v", m.Err())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m = MustCompile(pattern).MatcherContext(ctx, input)
+	if m.Matches() {
+		t.Errorf("Matches: unexpected acceptance")
+	}
+	if m.Err() != context.Canceled {
+		t.Errorf("Err: expected %v, got %v", context.Canceled, m.Err())
+	}
+}
+
+func TestMatcher_Trace(t *testing.T) {
+	g := MustCompile("src/*.go")
+
+	m := g.Matcher("src/main.c")
+	m.EnableTrace()
+	if m.Matches() {
+		t.Fatalf("expected no match")
+	}
+	if events := m.Trace().Events(); len(events) != 1 || events[0].Reason != AffixReason {
+		t.Errorf("fast reject: expected one AffixReason event, got %+v", events)
+	}
+
+	g = MustCompile("[abc]*.go")
+	m = g.Matcher("d.go")
+	if m.Trace() != nil {
+		t.Errorf("Trace() before EnableTrace: expected nil")
+	}
+	m.EnableTrace()
+	if m.Matches() {
+		t.Fatalf("expected no match")
+	}
+	trace := m.Trace()
+	events := trace.Events()
+	if len(events) == 0 {
+		t.Fatalf("expected events")
+	}
+	last := events[len(events)-1]
+	if last.Kind != RejectEvent || last.Reason != RuneClassReason {
+		t.Errorf("last event: expected RejectEvent/RuneClassReason, got %v/%v", last.Kind, last.Reason)
+	}
+	if stats := trace.Stats(); stats.Tries != 1 || stats.Rejects != 1 {
+		t.Errorf("Stats: unexpected %+v", stats)
+	}
+	str := trace.String()
+	for _, want := range []string{`#0 "[abc]"`, `rune is not in character set`, "rejected after"} {
+		if !strings.Contains(str, want) {
+			t.Errorf("String: expected %q in:\n%s", want, str)
+		}
+	}
+
+	m.Reset("a.b.go")
+	if !m.Matches() {
+		t.Fatalf("expected match")
+	}
+	trace = m.Trace()
+	if stats := trace.Stats(); stats.Rejects != 0 || stats.Candidates == 0 || stats.MaxDepth != 1 {
+		t.Errorf("Stats after Reset: unexpected %+v", stats)
+	}
+	if str := trace.String(); !strings.Contains(str, "matched after") {
+		t.Errorf("String after Reset: expected a match in:\n%s", str)
+	}
+	t.Logf("\n%s", trace)
+}
+
+func TestMatcher_Failure(t *testing.T) {
+	type testRow struct {
+		Pattern  string
+		Input    string
+		Matched  string
+		Segment  int
+		Reason   RejectReason
+		Expected string
+	}
+
+	testData := [...]testRow{
+		{"src/*.go", "src/main.go", "", 0, NoReason, ""},
+		{"src/*.go", "lib/main.go", "", 0, LiteralMismatchReason, `expected "src/", found 'l' at offset 0`},
+		{"src/*.go", "src/a/b.go", "src/a", 2, LiteralMismatchReason, `expected ".go", found '/' at offset 5`},
+		{"src/*.go", "src/main.c", "src/main.c", 2, EndOfInputReason, `expected ".go", found end of input at offset 10`},
+		{"[abc]?x", "d", "", 0, RuneClassReason, `expected one of [a-c], found 'd' at offset 0`},
+		{"a?x", "a/x", "a", 1, SeparatorReason, `expected any rune except '/', found '/' at offset 1`},
+		{"a", "ab", "a", -1, TrailingInputReason, `expected end of input, found "b" at offset 1`},
+	}
+
+	for _, row := range testData {
+		name := row.Pattern + "/" + row.Input
+		t.Run(name, func(t *testing.T) {
+			g := MustCompile(row.Pattern)
+			m := g.Matcher(row.Input)
+			ok := m.Matches()
+			f := m.Failure()
+			if row.Reason == NoReason {
+				if !ok || f != nil {
+					t.Errorf("expected match and nil Failure, got %v, %v", ok, f)
+				}
+				return
+			}
+			if ok || f == nil {
+				t.Fatalf("expected no match and a Failure, got %v, %v", ok, f)
+			}
+			if f.Matched != row.Matched {
+				t.Errorf("Matched: expected %q, got %q", row.Matched, f.Matched)
+			}
+			if f.Reason != row.Reason {
+				t.Errorf("Reason: expected %v, got %v", row.Reason, f.Reason)
+			}
+			segment := -1
+			if f.Segment != nil {
+				segment = int(f.Segment.Index())
+			}
+			if segment != row.Segment {
+				t.Errorf("Segment: expected %d, got %d", row.Segment, segment)
+			}
+			if str := f.String(); str != row.Expected {
+				t.Errorf("String: expected %q, got %q", row.Expected, str)
 			}
 		})
 	}
@@ -0,0 +1,412 @@
+package guts
+
+import (
+	"sort"
+	"unicode"
+)
+
+// NFA is a nondeterministic automaton which accepts exactly the strings
+// matched by a Glob.  State 0 is the start state and the last state is the
+// only accepting state.
+type NFA struct {
+	Edges   [][]NFAEdge
+	Epsilon [][]uint
+}
+
+// NFAEdge is a transition on any rune within Ranges.  NoLeadingDot marks
+// the edges which may not consume a '.' at the start of a path component
+// when dotfiles are hidden: every wildcard, and every literal rune which
+// does not begin a component of the pattern.
+type NFAEdge struct {
+	Ranges       SortedLoHi
+	To           uint
+	NoLeadingDot bool
+}
+
+var (
+	anyRanges      = SortedLoHi{{0, unicode.MaxRune}}
+	notSlashRanges = SortedLoHi{{0, '/' - 1}, {'/' + 1, unicode.MaxRune}}
+	slashRanges    = SortedLoHi{{'/', '/'}}
+)
+
+// BuildNFA translates the segments of g into an NFA.
+func BuildNFA(g *Glob) *NFA {
+	a := &NFA{}
+	pos := a.AddState()
+	for i := range g.Segments {
+		seg := &g.Segments[i]
+		switch seg.Type {
+		case LiteralSegment:
+			for k, ch := range seg.Literal.Runes {
+				startsComponent := g.StartsComponent(uint(i))
+				if k > 0 {
+					startsComponent = seg.Literal.Runes[k-1] == '/'
+				}
+				next := a.AddState()
+				a.addEdge(pos, SortedLoHi{{ch, ch}}, next, !startsComponent)
+				pos = next
+			}
+
+		case RuneMatchSegment:
+			next := a.AddState()
+			a.addEdge(pos, Ranges(seg.Matcher), next, true)
+			pos = next
+
+		case QuestionSegment:
+			next := a.AddState()
+			a.addEdge(pos, notSlashRanges, next, true)
+			pos = next
+
+		case StarSegment, DoubleStarSegment:
+			ranges := notSlashRanges
+			if seg.Type == DoubleStarSegment {
+				ranges = anyRanges
+			}
+			loop := a.AddState()
+			a.AddEpsilon(pos, loop)
+			a.addEdge(loop, ranges, loop, true)
+			pos = loop
+
+		case DoubleStarSlashSegment:
+			// Equivalent to "(.*/)?".
+			inside := a.AddState()
+			next := a.AddState()
+			a.AddEpsilon(pos, next)
+			a.AddEdge(pos, slashRanges, next)
+			a.addEdge(pos, anyRanges, inside, true)
+			a.addEdge(inside, anyRanges, inside, true)
+			a.AddEdge(inside, slashRanges, next)
+			pos = next
+		}
+	}
+	if g.Options.HideDotfiles {
+		return a.hideDotfiles()
+	}
+	return a
+}
+
+// hideDotfiles splits each state in two, according to whether the last rune
+// consumed was a '/', so that the edges marked NoLeadingDot can refuse a '.'
+// at the start of a path component.  State 2s is state s at the start of a
+// component, and state 2s+1 is state s within one.
+func (a *NFA) hideDotfiles() *NFA {
+	n := uint(len(a.Edges))
+	out := &NFA{}
+	for i := uint(0); i <= 2*n; i++ {
+		out.AddState()
+	}
+	for s := uint(0); s < n; s++ {
+		for within := uint(0); within < 2; within++ {
+			from := 2*s + within
+			for _, to := range a.Epsilon[s] {
+				out.AddEpsilon(from, 2*to+within)
+			}
+			for _, edge := range a.Edges[s] {
+				ranges := edge.Ranges
+				if within == 0 && edge.NoLeadingDot {
+					ranges = withoutRune(ranges, '.')
+				}
+				if containsRune(ranges, '/') {
+					out.AddEdge(from, slashRanges, 2*edge.To)
+				}
+				if rest := withoutRune(ranges, '/'); len(rest) > 0 {
+					out.AddEdge(from, rest, 2*edge.To+1)
+				}
+			}
+		}
+	}
+	accept := a.Accept()
+	out.AddEpsilon(2*accept, 2*n)
+	out.AddEpsilon(2*accept+1, 2*n)
+	return out
+}
+
+func withoutRune(ranges SortedLoHi, ch rune) SortedLoHi {
+	if !containsRune(ranges, ch) {
+		return ranges
+	}
+	out := make(SortedLoHi, 0, len(ranges)+1)
+	for _, r := range ranges {
+		if ch < r.Lo || ch > r.Hi {
+			out = append(out, r)
+			continue
+		}
+		if r.Lo < ch {
+			out = append(out, LoHi{r.Lo, ch - 1})
+		}
+		if ch < r.Hi {
+			out = append(out, LoHi{ch + 1, r.Hi})
+		}
+	}
+	return out
+}
+
+func (a *NFA) AddState() uint {
+	a.Edges = append(a.Edges, nil)
+	a.Epsilon = append(a.Epsilon, nil)
+	return uint(len(a.Edges) - 1)
+}
+
+func (a *NFA) AddEdge(from uint, ranges SortedLoHi, to uint) {
+	a.addEdge(from, ranges, to, false)
+}
+
+func (a *NFA) addEdge(from uint, ranges SortedLoHi, to uint, noLeadingDot bool) {
+	a.Edges[from] = append(a.Edges[from], NFAEdge{Ranges: ranges, To: to, NoLeadingDot: noLeadingDot})
+}
+
+func (a *NFA) AddEpsilon(from, to uint) {
+	a.Epsilon[from] = append(a.Epsilon[from], to)
+}
+
+func (a *NFA) Accept() uint {
+	return uint(len(a.Edges) - 1)
+}
+
+// StateSet is a bitset of NFA states.
+type StateSet []uint64
+
+func newStateSet(n uint) StateSet {
+	return make(StateSet, (n+63)/64)
+}
+
+func (s StateSet) Has(i uint) bool {
+	return s[i/64]&(1<<(i%64)) != 0
+}
+
+func (s StateSet) Add(i uint) bool {
+	if s.Has(i) {
+		return false
+	}
+	s[i/64] |= 1 << (i % 64)
+	return true
+}
+
+func (s StateSet) IsEmpty() bool {
+	for _, word := range s {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s StateSet) appendKey(key []byte) []byte {
+	for _, word := range s {
+		for i := uint(0); i < 64; i += 8 {
+			key = append(key, byte(word>>i))
+		}
+	}
+	return key
+}
+
+// Closure adds every state reachable from s by epsilon transitions.
+func (a *NFA) Closure(s StateSet) {
+	var stack []uint
+	for i := uint(0); i < uint(len(a.Edges)); i++ {
+		if s.Has(i) {
+			stack = append(stack, i)
+		}
+	}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, j := range a.Epsilon[i] {
+			if s.Add(j) {
+				stack = append(stack, j)
+			}
+		}
+	}
+}
+
+// Step returns the states reachable from s by consuming ch.
+func (a *NFA) Step(s StateSet, ch rune) StateSet {
+	out := newStateSet(uint(len(a.Edges)))
+	a.StepInto(out, s, ch)
+	return out
+}
+
+// StepInto is like Step, but overwrites out instead of allocating.
+func (a *NFA) StepInto(out, s StateSet, ch rune) {
+	for i := range out {
+		out[i] = 0
+	}
+	for i := uint(0); i < uint(len(a.Edges)); i++ {
+		if !s.Has(i) {
+			continue
+		}
+		for _, edge := range a.Edges[i] {
+			if containsRune(edge.Ranges, ch) {
+				out.Add(edge.To)
+			}
+		}
+	}
+	a.Closure(out)
+}
+
+// Append copies the states of b into a, and returns the new numbers of b's
+// start and accepting states.
+func (a *NFA) Append(b *NFA) (uint, uint) {
+	offset := uint(len(a.Edges))
+	for i := range b.Edges {
+		state := a.AddState()
+		for _, edge := range b.Edges[i] {
+			a.addEdge(state, edge.Ranges, edge.To+offset, edge.NoLeadingDot)
+		}
+		for _, to := range b.Epsilon[i] {
+			a.AddEpsilon(state, to+offset)
+		}
+	}
+	return offset, offset + b.Accept()
+}
+
+// MultiNFA matches many patterns at once.  State 0 is a common start state,
+// and Accepts holds the accepting state of each pattern.
+type MultiNFA struct {
+	NFA
+	Accepts []uint
+}
+
+func BuildMultiNFA(globs []*Glob) *MultiNFA {
+	a := &MultiNFA{}
+	a.AddState()
+	for _, g := range globs {
+		start, accept := a.Append(BuildNFA(g))
+		a.AddEpsilon(0, start)
+		a.Accepts = append(a.Accepts, accept)
+	}
+	return a
+}
+
+func (a *NFA) Start() StateSet {
+	s := newStateSet(uint(len(a.Edges)))
+	s.Add(0)
+	a.Closure(s)
+	return s
+}
+
+func containsRune(ranges SortedLoHi, ch rune) bool {
+	i := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].Hi >= ch
+	})
+	return i < len(ranges) && ranges[i].Lo <= ch
+}
+
+// Partition splits the runes into classes, such that every edge of every
+// NFA either contains a whole class or none of it, and returns one rune from
+// each class.
+func Partition(nfas []*NFA) []rune {
+	cuts := map[rune]struct{}{0: {}}
+	for _, a := range nfas {
+		for _, edges := range a.Edges {
+			for _, edge := range edges {
+				for _, r := range edge.Ranges {
+					cuts[r.Lo] = struct{}{}
+					if r.Hi < unicode.MaxRune {
+						cuts[r.Hi+1] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+
+	los := make([]rune, 0, len(cuts))
+	for lo := range cuts {
+		los = append(los, lo)
+	}
+	sort.Slice(los, func(i, j int) bool { return los[i] < los[j] })
+
+	out := make([]rune, len(los))
+	for i, lo := range los {
+		hi := rune(unicode.MaxRune)
+		if i+1 < len(los) {
+			hi = los[i+1] - 1
+		}
+		out[i] = representative(lo, hi)
+	}
+	return out
+}
+
+// representative picks a rune from [lo..hi] which is pleasant to read in an
+// example string.
+func representative(lo, hi rune) rune {
+	const preferred = "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_-."
+	for _, ch := range preferred {
+		if ch >= lo && ch <= hi {
+			return ch
+		}
+	}
+	for ch := lo; ch <= hi && ch < lo+0x100; ch++ {
+		if unicode.IsGraphic(ch) {
+			return ch
+		}
+	}
+	return lo
+}
+
+// Search explores the product of the given NFAs, breadth first, for a string
+// which the NFAs accept or reject in a combination for which pred returns
+// true.  pred receives one flag per NFA, true iff that NFA accepts.  Search
+// returns the shortest such string, or false if there is none.
+//
+// The cost is exponential in the worst case, as with any subset
+// construction, but globs rarely come close.
+func Search(nfas []*NFA, pred func(accepts []bool) bool) (string, bool) {
+	type node struct {
+		sets   []StateSet
+		parent int
+		ch     rune
+	}
+
+	alphabet := Partition(nfas)
+	accepts := make([]bool, len(nfas))
+
+	key := func(sets []StateSet) string {
+		var buf []byte
+		for _, s := range sets {
+			buf = s.appendKey(buf)
+		}
+		return string(buf)
+	}
+
+	check := func(sets []StateSet) bool {
+		for i, a := range nfas {
+			accepts[i] = sets[i].Has(a.Accept())
+		}
+		return pred(accepts)
+	}
+
+	start := make([]StateSet, len(nfas))
+	for i, a := range nfas {
+		start[i] = a.Start()
+	}
+
+	queue := []node{{sets: start, parent: -1}}
+	seen := map[string]struct{}{key(start): {}}
+	for head := 0; head < len(queue); head++ {
+		if check(queue[head].sets) {
+			var runes []rune
+			for i := head; queue[i].parent >= 0; i = queue[i].parent {
+				runes = append(runes, queue[i].ch)
+			}
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			return EncodeRawRunes(runes), true
+		}
+
+		for _, ch := range alphabet {
+			sets := make([]StateSet, len(nfas))
+			for i, a := range nfas {
+				sets[i] = a.Step(queue[head].sets[i], ch)
+			}
+			k := key(sets)
+			if _, found := seen[k]; found {
+				continue
+			}
+			seen[k] = struct{}{}
+			queue = append(queue, node{sets: sets, parent: head, ch: ch})
+		}
+	}
+	return "", false
+}
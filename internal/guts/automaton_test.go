@@ -0,0 +1,171 @@
+package guts
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNFA_AgainstReference(t *testing.T) {
+	patterns := []string{
+		"",
+		"a",
+		"*",
+		"**",
+		"**/",
+		"*/*",
+		"a*b*c",
+		"**/a/**/b",
+		"?*?/**",
+		"[ab]*[^a]**/x",
+		"**a**",
+		"**/**/a",
+	}
+	alphabet := []rune("ab/c.x")
+	rng := rand.New(rand.NewSource(1))
+
+	for _, pattern := range patterns {
+		var g Glob
+		if err := g.Compile(pattern); err != nil {
+			t.Errorf("%q: unexpected error: %v", pattern, err)
+			continue
+		}
+		a := BuildNFA(&g)
+		for n := 0; n < 500; n++ {
+			runes := make([]rune, rng.Intn(10))
+			for i := range runes {
+				runes[i] = alphabet[rng.Intn(len(alphabet))]
+			}
+
+			s := a.Start()
+			for _, ch := range runes {
+				s = a.Step(s, ch)
+			}
+			actual := s.Has(a.Accept())
+			expect := referenceMatch(g.Segments, runes)
+			if actual != expect {
+				t.Errorf("%q against %q: expected %v, got %v", string(runes), pattern, expect, actual)
+			}
+		}
+	}
+}
+
+func TestHideDotfiles(t *testing.T) {
+	type testRow struct {
+		Pattern string
+		Input   string
+		Expect  bool
+	}
+
+	testData := [...]testRow{
+		{"*.go", "a.go", true},
+		{"*.go", ".go", false},
+		{".*", ".x", true},
+		{"a*", "a.b", true},
+		{"?x", ".x", false},
+		{"[.]x", ".x", false},
+		{"a/.b", "a/.b", true},
+		{"a/*", "a/.b", false},
+		{"**/*.go", "a/b.go", true},
+		{"**/*.go", ".git/a.go", false},
+		{"**/*.go", "a/.git/b.go", false},
+		{"**/.git", "x/.git", true},
+		{"**/.git", ".git", true},
+		{"a/**", "a/b/c", true},
+		{"a/**", "a/.x", false},
+		{"a/**", "a/b/.x", false},
+		{"a**", "a/.x", false},
+	}
+
+	opts := Options{HideDotfiles: true}
+	for _, row := range testData {
+		var g Glob
+		if err := g.CompileWith(row.Pattern, opts); err != nil {
+			t.Errorf("%q: unexpected error: %v", row.Pattern, err)
+			continue
+		}
+		var m Matcher
+		g.Matcher(&m, row.Input)
+		if actual := m.Matches(&g); actual != row.Expect {
+			t.Errorf("%q against %q: expected %v, got %v", row.Input, row.Pattern, row.Expect, actual)
+		}
+	}
+
+	// The matcher, the fast path and the NFA must agree.
+	patterns := []string{"*", "**", "**/", "*/*", ".*/**", "**/.x*", "a*b", "?*/**/x", "[.a]*", "**a**", "a/**/b"}
+	alphabet := []rune("ab/.x")
+	rng := rand.New(rand.NewSource(1))
+	for _, pattern := range patterns {
+		var g Glob
+		if err := g.CompileWith(pattern, opts); err != nil {
+			t.Errorf("%q: unexpected error: %v", pattern, err)
+			continue
+		}
+		a := BuildNFA(&g)
+		for n := 0; n < 500; n++ {
+			runes := make([]rune, rng.Intn(10))
+			for i := range runes {
+				runes[i] = alphabet[rng.Intn(len(alphabet))]
+			}
+			input := string(runes)
+
+			var m Matcher
+			g.Matcher(&m, input)
+			expect := m.Matches(&g)
+
+			s := a.Start()
+			for _, ch := range runes {
+				s = a.Step(s, ch)
+			}
+			if actual := s.Has(a.Accept()); actual != expect {
+				t.Errorf("NFA: %q against %q: expected %v, got %v", input, pattern, expect, actual)
+			}
+			if actual, ok := g.MatchFast(input); ok && actual != expect {
+				t.Errorf("MatchFast: %q against %q: expected %v, got %v", input, pattern, expect, actual)
+			}
+		}
+	}
+}
+
+func TestLazyDFA_Reset(t *testing.T) {
+	// "**a???????" needs a DFA state for every combination of the last
+	// eight runes, far more than MaxStates.
+	patterns := []string{"**a???????", "b*"}
+	globs := make([]*Glob, len(patterns))
+	for i, pattern := range patterns {
+		globs[i] = new(Glob)
+		if err := globs[i].Compile(pattern); err != nil {
+			t.Fatalf("%q: unexpected error: %v", pattern, err)
+		}
+	}
+	d := NewLazyDFA(BuildMultiNFA(globs))
+	d.MaxStates = 16
+
+	rng := rand.New(rand.NewSource(1))
+	resets := 0
+	start := d.start
+	for n := 0; n < 500; n++ {
+		runes := make([]rune, rng.Intn(20))
+		for i := range runes {
+			runes[i] = rune('a' + rng.Intn(2))
+		}
+		expect := -1
+		for i, g := range globs {
+			if referenceMatch(g.Segments, runes) {
+				expect = i
+			}
+		}
+		if last := d.Run(string(runes)).Last; last != expect {
+			t.Errorf("%q: expected %d, got %d", string(runes), expect, last)
+		}
+		if len(d.states) > d.MaxStates {
+			t.Fatalf("%d states cached, limit is %d", len(d.states), d.MaxStates)
+		}
+		if d.start != start {
+			resets++
+			start = d.start
+		}
+	}
+	if resets == 0 {
+		t.Errorf("expected the cache to be reset")
+	}
+}
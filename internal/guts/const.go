@@ -6,3 +6,8 @@ const (
 	IntMax  = int(UintMax >> 1)
 	IntMin  = ^IntMax
 )
+
+const (
+	RawByteLo = rune(0xdc80)
+	RawByteHi = rune(0xdcff)
+)
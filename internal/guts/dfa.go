@@ -0,0 +1,114 @@
+package guts
+
+import (
+	"sync"
+)
+
+// MaxDFAStates is the default LazyDFA.MaxStates.
+const MaxDFAStates = 4096
+
+// LazyDFA determinizes a MultiNFA on demand, caching the states and
+// transitions which inputs actually visit.  It is safe for concurrent use.
+//
+// MaxStates bounds the memory used by the cache.  When it fills up, every
+// state and transition is discarded, and the cache is rebuilt as needed from
+// a new start state.
+type LazyDFA struct {
+	NFA       *MultiNFA
+	MaxStates int
+	mu        sync.RWMutex
+	states    map[string]*DFAState
+	start     *DFAState
+}
+
+// DFAState is a set of NFA states.  Last is the index of the last pattern
+// whose accepting state is in the set, or -1 if there is none.
+type DFAState struct {
+	Set   StateSet
+	Last  int
+	Dead  bool
+	ascii [128]*DFAState
+	other map[rune]*DFAState
+}
+
+func NewLazyDFA(a *MultiNFA) *LazyDFA {
+	d := &LazyDFA{NFA: a, MaxStates: MaxDFAStates}
+	d.reset()
+	return d
+}
+
+// reset discards the cache.  A Run in progress finishes on the old states,
+// which then become garbage, since nothing new links to them.  The caller
+// must hold the write lock, or be the constructor.
+func (d *LazyDFA) reset() {
+	d.states = make(map[string]*DFAState)
+	d.start = nil
+	d.start = d.intern(d.NFA.Start())
+}
+
+// Run returns the state reached after consuming all of input, which is
+// decoded as by DecodeRawRune.
+func (d *LazyDFA) Run(input string) *DFAState {
+	d.mu.RLock()
+	s := d.start
+	d.mu.RUnlock()
+	for i := 0; i < len(input) && !s.Dead; {
+		ch, size := DecodeRawRune(input[i:])
+		s = d.next(s, ch)
+		i += size
+	}
+	return s
+}
+
+func (d *LazyDFA) next(s *DFAState, ch rune) *DFAState {
+	d.mu.RLock()
+	t := s.lookup(ch)
+	d.mu.RUnlock()
+	if t != nil {
+		return t
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if t = s.lookup(ch); t != nil {
+		return t
+	}
+	t = d.intern(d.NFA.Step(s.Set, ch))
+	if ch >= 0 && ch < 128 {
+		s.ascii[ch] = t
+	} else {
+		if s.other == nil {
+			s.other = make(map[rune]*DFAState)
+		}
+		s.other[ch] = t
+	}
+	return t
+}
+
+func (s *DFAState) lookup(ch rune) *DFAState {
+	if ch >= 0 && ch < 128 {
+		return s.ascii[ch]
+	}
+	return s.other[ch]
+}
+
+// intern returns the cached state for set, creating it if necessary.  The
+// caller must hold the write lock, or be the constructor.
+func (d *LazyDFA) intern(set StateSet) *DFAState {
+	key := string(set.appendKey(nil))
+	if s, found := d.states[key]; found {
+		return s
+	}
+	if d.MaxStates > 0 && len(d.states) >= d.MaxStates {
+		d.reset()
+	}
+	s := &DFAState{Set: set, Last: -1, Dead: set.IsEmpty()}
+	for i := len(d.NFA.Accepts) - 1; i >= 0; i-- {
+		if set.Has(d.NFA.Accepts[i]) {
+			s.Last = i
+			break
+		}
+	}
+	d.states[key] = s
+	return s
+}
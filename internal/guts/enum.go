@@ -2,6 +2,8 @@ package guts
 
 import (
 	"fmt"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 const (
@@ -83,3 +85,178 @@ func (x ParseState) GoString() string {
 	}
 	return parseStateNames[x]
 }
+
+const (
+	UnexpectedRuneError ErrorKind = iota
+	UnsupportedSyntaxError
+	InvalidEscapeError
+	InvalidRangeError
+	UnterminatedSetError
+	UnterminatedEscapeError
+	LimitError
+)
+
+var errorKindNames = []string{
+	"UnexpectedRuneError",
+	"UnsupportedSyntaxError",
+	"InvalidEscapeError",
+	"InvalidRangeError",
+	"UnterminatedSetError",
+	"UnterminatedEscapeError",
+	"LimitError",
+}
+
+func (x ErrorKind) String() string {
+	if uint(x) >= uint(len(errorKindNames)) {
+		return fmt.Sprintf("%%!ErrorKind(%d)", x)
+	}
+	return errorKindNames[x]
+}
+
+func (x ErrorKind) GoString() string {
+	if uint(x) >= uint(len(errorKindNames)) {
+		return fmt.Sprintf("ErrorKind(%d)", x)
+	}
+	return errorKindNames[x]
+}
+
+const (
+	NFKCNorm NormForm = iota
+	NFCNorm
+	NFDNorm
+	NFKDNorm
+	NoNorm
+)
+
+var normFormNames = []string{
+	"NFKCNorm",
+	"NFCNorm",
+	"NFDNorm",
+	"NFKDNorm",
+	"NoNorm",
+}
+
+// normForms maps each NormForm to the form in which patterns and inputs are
+// matched.  The decomposed forms are matched composed, which is equivalent,
+// so that a character set or '?' sees an accented letter as one rune rather
+// than a letter and a combining mark.
+var normForms = []norm.Form{
+	norm.NFKC,
+	norm.NFC,
+	norm.NFC,
+	norm.NFKC,
+}
+
+func (x NormForm) Form() norm.Form {
+	if uint(x) >= uint(len(normForms)) {
+		panic(fmt.Errorf("BUG! NormForm %#v has no norm.Form", x))
+	}
+	return normForms[x]
+}
+
+func (x NormForm) String() string {
+	if uint(x) >= uint(len(normFormNames)) {
+		return fmt.Sprintf("%%!NormForm(%d)", x)
+	}
+	return normFormNames[x]
+}
+
+func (x NormForm) GoString() string {
+	if uint(x) >= uint(len(normFormNames)) {
+		return fmt.Sprintf("NormForm(%d)", x)
+	}
+	return normFormNames[x]
+}
+
+const (
+	TryEvent TraceEventType = iota
+	MemoHitEvent
+	CandidateEvent
+	AcceptEvent
+	RejectEvent
+)
+
+var traceEventTypeNames = []string{
+	"TryEvent",
+	"MemoHitEvent",
+	"CandidateEvent",
+	"AcceptEvent",
+	"RejectEvent",
+}
+
+func (x TraceEventType) String() string {
+	if uint(x) >= uint(len(traceEventTypeNames)) {
+		return fmt.Sprintf("%%!TraceEventType(%d)", x)
+	}
+	return traceEventTypeNames[x]
+}
+
+func (x TraceEventType) GoString() string {
+	if uint(x) >= uint(len(traceEventTypeNames)) {
+		return fmt.Sprintf("TraceEventType(%d)", x)
+	}
+	return traceEventTypeNames[x]
+}
+
+const (
+	NoReason RejectReason = iota
+	LengthBoundReason
+	AffixReason
+	LiteralMismatchReason
+	RuneClassReason
+	SeparatorReason
+	EndOfInputReason
+	TrailingInputReason
+	MemoizedReason
+	BudgetReason
+	DotfileReason
+)
+
+var rejectReasonNames = []string{
+	"NoReason",
+	"LengthBoundReason",
+	"AffixReason",
+	"LiteralMismatchReason",
+	"RuneClassReason",
+	"SeparatorReason",
+	"EndOfInputReason",
+	"TrailingInputReason",
+	"MemoizedReason",
+	"BudgetReason",
+	"DotfileReason",
+}
+
+var rejectReasonMessages = []string{
+	"",
+	"remaining input is too short or too long",
+	"input lacks the pattern's fixed prefix or suffix",
+	"input does not match literal",
+	"rune is not in character set",
+	"'?' does not match '/'",
+	"unexpected end of input",
+	"input remains after end of pattern",
+	"previously rejected at this position",
+	"match was abandoned",
+	"leading '.' of path component must be matched explicitly",
+}
+
+func (x RejectReason) Message() string {
+	if uint(x) >= uint(len(rejectReasonMessages)) {
+		return x.String()
+	}
+	return rejectReasonMessages[x]
+}
+
+func (x RejectReason) String() string {
+	if uint(x) >= uint(len(rejectReasonNames)) {
+		return fmt.Sprintf("%%!RejectReason(%d)", x)
+	}
+	return rejectReasonNames[x]
+}
+
+func (x RejectReason) GoString() string {
+	if uint(x) >= uint(len(rejectReasonNames)) {
+		return fmt.Sprintf("RejectReason(%d)", x)
+	}
+	return rejectReasonNames[x]
+}
@@ -0,0 +1,52 @@
+package guts
+
+// Explain re-runs the match from the start of the input with the fast
+// rejects disabled, and reports the furthest point that any attempt reached
+// before being rejected.  It returns false if the input matches, or if the
+// budget ran out before an explanation was found.
+func (m *Matcher) Explain(g *Glob) (Failure, bool) {
+	var f Failure
+	var budget Budget
+	x := Matcher{Form: m.Form, Failure: &f}
+	if m.Budget != nil {
+		budget = Budget{Context: m.Budget.Context, MaxSteps: m.Budget.MaxSteps}
+		x.Budget = &budget
+	}
+	x.Reset(g, m.Input.String)
+	if x.Matches(g) || x.Aborted() || !f.Found {
+		return Failure{}, false
+	}
+	return f, true
+}
+
+// Observe records a rejection if it got further into the input than any
+// rejection seen so far; ties go to the rejection further into the pattern.
+func (f *Failure) Observe(m *Matcher, reason RejectReason, segmentI uint, seg *Segment, inputP uint) {
+	switch reason {
+	case MemoizedReason, BudgetReason, LengthBoundReason, AffixReason:
+		// Not a real mismatch, or a repeat of one already observed.
+		return
+	}
+
+	inputI := inputP
+	if seg != nil && seg.Type == LiteralSegment && reason != DotfileReason {
+		// Count the runes of the literal which did match.
+		for _, ch := range seg.Literal.Runes {
+			if inputI >= m.InputJ || m.Input.Runes[inputI] != ch {
+				break
+			}
+			inputI++
+		}
+	}
+
+	if f.Found && (inputI < f.InputI || (inputI == f.InputI && segmentI <= f.SegmentI)) {
+		return
+	}
+	*f = Failure{
+		Found:    true,
+		Reason:   reason,
+		SegmentI: segmentI,
+		InputP:   inputP,
+		InputI:   inputI,
+	}
+}
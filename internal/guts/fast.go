@@ -0,0 +1,151 @@
+package guts
+
+import (
+	"unicode/utf8"
+)
+
+// MatchFast matches the entire input without allocating, when possible.  It
+// handles ASCII input, which is unchanged by every normalization form, for
+// patterns with at most one star; with only one star there is only one place
+// to backtrack, so no memoization is needed.  The second return value is
+// false if the input or pattern was not eligible.
+func (g *Glob) MatchFast(input string) (bool, bool) {
+	if g.NumStars > 1 || !IsASCII(input) {
+		return false, false
+	}
+	if !hasSuffixASCII(input, g.Suffix) {
+		return false, true
+	}
+	return g.matchASCII(input, 0, 0), true
+}
+
+func hasSuffixASCII(input string, suffix []rune) bool {
+	offset := len(input) - len(suffix)
+	if offset < 0 {
+		return false
+	}
+	for i, ch := range suffix {
+		if rune(input[offset+i]) != ch {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Glob) matchASCII(input string, inputI uint, segmentI uint) bool {
+	inputL := uint(len(input))
+	segmentJ := uint(len(g.Segments))
+	for segmentI < segmentJ {
+		seg := &g.Segments[segmentI]
+		segmentI++
+
+		remain := inputL - inputI
+		if remain < seg.MinLength || remain > seg.MaxLength {
+			return false
+		}
+
+		// See Matcher.Tick.
+		if g.Options.HideDotfiles && isDotfileAtASCII(input, inputI) {
+			switch seg.Type {
+			case LiteralSegment:
+				if !g.StartsComponent(segmentI - 1) {
+					return false
+				}
+			case DoubleStarSlashSegment:
+				// May match the empty string before it.
+			default:
+				return false
+			}
+		}
+
+		switch seg.Type {
+		case LiteralSegment:
+			for _, ch := range seg.Literal.Runes {
+				if rune(input[inputI]) != ch {
+					return false
+				}
+				inputI++
+			}
+
+		case RuneMatchSegment:
+			if !seg.Matcher.MatchRune(rune(input[inputI])) {
+				return false
+			}
+			inputI++
+
+		case QuestionSegment:
+			if input[inputI] == '/' {
+				return false
+			}
+			inputI++
+
+		case StarSegment:
+			inputJ := inputI
+			for inputJ < inputL && input[inputJ] != '/' {
+				inputJ++
+			}
+			for {
+				if g.matchASCII(input, inputJ, segmentI) {
+					return true
+				}
+				if inputJ == inputI {
+					return false
+				}
+				inputJ--
+			}
+
+		case DoubleStarSegment:
+			limit := inputL
+			if g.Options.HideDotfiles && inputI < inputL {
+				limit = visibleUntilASCII(input, inputI+1)
+			}
+			for inputJ := limit; ; inputJ-- {
+				if g.matchASCII(input, inputJ, segmentI) {
+					return true
+				}
+				if inputJ == inputI {
+					return false
+				}
+			}
+
+		case DoubleStarSlashSegment:
+			limit := inputL
+			if g.Options.HideDotfiles {
+				limit = visibleUntilASCII(input, inputI)
+			}
+			for inputJ := limit; ; inputJ-- {
+				atBoundary := (inputJ == inputI || input[inputJ-1] == '/')
+				if atBoundary && g.matchASCII(input, inputJ, segmentI) {
+					return true
+				}
+				if inputJ == inputI {
+					return false
+				}
+			}
+		}
+	}
+	return inputI == inputL
+}
+
+func isDotfileAtASCII(input string, i uint) bool {
+	return i < uint(len(input)) && input[i] == '.' && (i == 0 || input[i-1] == '/')
+}
+
+func visibleUntilASCII(input string, i uint) uint {
+	n := uint(len(input))
+	for ; i < n; i++ {
+		if isDotfileAtASCII(input, i) {
+			return i
+		}
+	}
+	return n
+}
+
+func IsASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -1,41 +1,100 @@
 package guts
 
-import (
-	"fmt"
-)
-
 func (g *Glob) Compile(input string) error {
+	return g.CompileWith(input, Options{})
+}
+
+func (g *Glob) CompileWith(input string, opts Options) error {
 	*g = Glob{}
 
 	var p Parser
-	p.Input = Norm(input)
+	p.Form = opts.Form
+	p.Input = Normalize(p.Form, input)
 	p.InputJ = uint(len(p.Input.Runes))
 	p.Segments = make([]Segment, 0, 16)
 	p.State = RootState
 	p.WantSet = false
-	p.Run()
+	if max := opts.Limits.MaxPatternLength; max != 0 && p.InputJ > max {
+		p.FailAt(LimitError, max, "pattern is longer than the limit of %d runes", max)
+	} else {
+		p.Run()
+		p.CheckLimits(opts.Limits)
+	}
 
 	if p.Err != nil {
-		return fmt.Errorf("failed to parse glob pattern: %q: %v", p.Input.String, p.Err)
+		p.Err.Context = "glob pattern"
+		return p.Err
 	}
 
+	g.Options = opts
 	g.Pattern = p.Input
 	g.Segments = p.Segments
 	g.MinLength = p.MinLength
 	g.MaxLength = p.MaxLength
+	g.NumStars = p.NumStars
+
+	// Record the literals which every match must begin and end with.
+	if n := len(g.Segments); n > 0 {
+		if first := &g.Segments[0]; first.Type == LiteralSegment {
+			g.Prefix = first.Literal.Runes
+		}
+		if last := &g.Segments[n-1]; last.Type == LiteralSegment {
+			g.Suffix = last.Literal.Runes
+		}
+	}
 	return nil
 }
 
+// StartsComponent returns true iff segment i begins a path component of the
+// pattern, i.e. it is first or follows a '/'.
+func (g *Glob) StartsComponent(i uint) bool {
+	if i == 0 {
+		return true
+	}
+	prev := &g.Segments[i-1]
+	switch prev.Type {
+	case LiteralSegment:
+		runes := prev.Literal.Runes
+		return len(runes) > 0 && runes[len(runes)-1] == '/'
+	case DoubleStarSlashSegment:
+		return true
+	}
+	return false
+}
+
+func (g *Glob) HasAffixes(runes []rune) bool {
+	return HasPrefixRunes(runes, g.Prefix) && HasSuffixRunes(runes, g.Suffix)
+}
+
 func (g *Glob) Matcher(out *Matcher, input string) {
-	*out = Matcher{}
-	out.Memo = make(MemoMap)
-	out.Input = Norm(input)
-	out.InputJ = uint(len(out.Input.Runes))
-	out.SegmentJ = uint(len(g.Segments))
-	minLength := g.MinLength
-	maxLength := g.MaxLength
-
-	// Fast reject the input is too short or too long to ever match;
-	// (*Matcher)(nil) is a valid matcher that will never match any string.
-	out.Valid = (out.InputJ >= minLength && out.InputJ <= maxLength)
+	*out = Matcher{Form: g.Options.Form}
+	out.Reset(g, input)
+}
+
+func (g *Glob) BytesMatcher(out *Matcher, input string) {
+	*out = Matcher{Form: NoNorm}
+	out.Reset(g, input)
+}
+
+func (g *Glob) LiteralValue() (string, bool) {
+	runes := takeRuneSlice(uint(len(g.Pattern.Runes)))
+	defer giveRuneSlice(runes)
+
+	for _, seg := range g.Segments {
+		switch seg.Type {
+		case LiteralSegment:
+			runes = append(runes, seg.Literal.Runes...)
+
+		case RuneMatchSegment:
+			is, ok := seg.Matcher.(*IsMatch)
+			if !ok {
+				return "", false
+			}
+			runes = append(runes, is.Rune)
+
+		default:
+			return "", false
+		}
+	}
+	return EncodeRawRunes(runes), true
 }
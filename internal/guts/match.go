@@ -1,74 +1,191 @@
 package guts
 
 import (
+	"errors"
 	"fmt"
 )
 
+var ErrStepLimit = errors.New("glob: match exceeded its step limit")
+
+// Reset prepares the matcher to match a new input against g, reusing the
+// memory allocated for the previous input.
+func (m *Matcher) Reset(g *Glob, input string) {
+	memo := m.Memo
+	exploded := m.Input
+	NormalizeInto(&exploded, m.Form, input)
+
+	budget := m.Budget
+	if budget != nil {
+		budget.Steps = 0
+		budget.Err = nil
+	}
+
+	trace := m.Trace
+	if trace != nil {
+		trace.Events = trace.Events[:0]
+		trace.Depth = 0
+	}
+
+	*m = Matcher{
+		Memo:     memo,
+		Budget:   budget,
+		Trace:    trace,
+		Failure:  m.Failure,
+		Input:    exploded,
+		InputJ:   uint(len(exploded.Runes)),
+		SegmentJ: uint(len(g.Segments)),
+		Form:     m.Form,
+	}
+	m.Memo.Reset(m.InputJ, m.SegmentJ, g.NumStars)
+
+	// Fast reject the input is too short or too long to ever match;
+	// (*Matcher)(nil) is a valid matcher that will never match any string.
+	m.Valid = true
+	if m.Failure != nil {
+		// Explaining a failure; the fast rejects would hide the reason.
+		return
+	}
+	if m.InputJ < g.MinLength || m.InputJ > g.MaxLength {
+		m.Reject(LengthBoundReason, 0, nil, 0, m.InputJ)
+		return
+	}
+
+	// Fast reject if the input lacks the pattern's fixed prefix or suffix.
+	if !g.HasAffixes(m.Input.Runes) {
+		m.Reject(AffixReason, 0, nil, 0, m.InputJ)
+	}
+}
+
+// Spend charges one step against the matcher's budget, if any.  It returns
+// false once the budget is exhausted or the context is cancelled.
+func (m *Matcher) Spend() bool {
+	b := m.Budget
+	if b == nil {
+		return true
+	}
+	if b.Err != nil {
+		return false
+	}
+	b.Steps++
+	if b.MaxSteps != 0 && b.Steps > b.MaxSteps {
+		b.Err = ErrStepLimit
+		return false
+	}
+	// Checking the context is comparatively expensive, so only do it
+	// every so often.
+	if b.Context != nil && b.Steps%1024 == 1 {
+		if err := b.Context.Err(); err != nil {
+			b.Err = err
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Matcher) Aborted() bool {
+	return m.Budget != nil && m.Budget.Err != nil
+}
+
+func (m *Matcher) Err() error {
+	if m.Budget == nil {
+		return nil
+	}
+	return m.Budget.Err
+}
+
 func (m *Matcher) HasNext(g *Glob) bool {
 	// Clear previous capture.
 	m.C = Capture{}
 
+	// Already rejected? Stay rejected.
+	if !m.Valid {
+		return false
+	}
+
+	// Out of budget? Reject.
+	if !m.Spend() {
+		m.Reject(BudgetReason, m.SegmentI, nil, m.InputI, m.InputI)
+		return false
+	}
+
 	// No more segments? Success iff all input was consumed.
 	if m.SegmentI >= m.SegmentJ {
-		m.Valid = m.Valid && (m.InputI >= m.InputJ)
+		if m.InputI < m.InputJ {
+			m.Reject(TrailingInputReason, m.SegmentI, nil, m.InputI, m.InputJ)
+		}
 		return false
 	}
 
-	// Grab next segment, and prepare memoization key while we're here.
-	key := MemoKey{InputI: m.InputI, SegmentI: m.SegmentI}
-	seg := g.Segments[m.SegmentI]
+	// Grab next segment, and locate its memoization cell while we're here.
+	inputI := m.InputI
+	segmentI := m.SegmentI
+	cell := m.Memo.Cell(inputI, segmentI)
+	seg := &g.Segments[segmentI]
 	m.SegmentI++
 	moreSegments := (m.SegmentI < m.SegmentJ)
 
 	// Fast reject if the remaining input is too short or too long to ever match.
-	remain := m.InputJ - m.InputI
-	if remain < seg.MinLength || remain > seg.MaxLength {
-		m.Valid = false
+	remain := m.InputJ - inputI
+	if m.Failure == nil && (remain < seg.MinLength || remain > seg.MaxLength) {
+		m.Reject(LengthBoundReason, segmentI, seg, inputI, m.InputJ)
 		return false
 	}
 
 	// Check for memoized results.
-	memo := m.Memo[key]
-	if memo != nil {
-		if memo.Rejected {
-			m.Valid = false
+	flags := m.Memo.Flags[cell]
+	if flags&MemoVisited != 0 {
+		if flags&MemoRejected != 0 {
+			m.Record(MemoHitEvent, NoReason, segmentI, seg, inputI, inputI, false)
+			m.Reject(MemoizedReason, segmentI, seg, inputI, inputI)
 			return false
 		}
-		if memo.Checked {
-			m.C = Capture{
-				InputP:   key.InputI,
-				InputQ:   memo.Index,
-				SegmentP: key.SegmentI,
-				PatternP: seg.PatternP,
-				PatternQ: seg.PatternQ,
-			}
-			m.InputI = memo.Index
-			return true
+		if flags&MemoChecked == 0 {
+			panic(fmt.Errorf("BUG! infinite recursion"))
 		}
-		panic(fmt.Errorf("BUG! infinite recursion"))
+		index := m.Memo.End(seg, inputI)
+		m.Record(MemoHitEvent, NoReason, segmentI, seg, inputI, index, true)
+		m.Accept(segmentI, seg, inputI, index)
+		return true
 	}
 
 	// Match some input, then memoize the outcome.
-	memo = &MemoValue{Index: UintMax}
-	m.Memo[key] = memo
-	index, ok := m.Tick(g, seg, moreSegments)
-	memo.Checked = true
-	memo.Rejected = false
-	memo.Index = index
-	if !ok {
-		memo.Rejected = true
-		m.Valid = false
+	m.Record(TryEvent, NoReason, segmentI, seg, inputI, inputI, false)
+	m.Memo.Flags[cell] |= MemoVisited
+	index, reason := m.Tick(g, *seg, moreSegments)
+	if m.Aborted() {
+		// The outcome is unknown, so don't memoize it.
+		m.Reject(BudgetReason, segmentI, seg, inputI, inputI)
+		return false
+	}
+	m.Memo.Flags[cell] |= MemoChecked
+	if reason != NoReason {
+		m.Memo.Flags[cell] |= MemoRejected
+		m.Reject(reason, segmentI, seg, inputI, index)
 		return false
 	}
+	m.Memo.SetEnd(seg, inputI, index)
+	m.Accept(segmentI, seg, inputI, index)
+	return true
+}
+
+func (m *Matcher) Accept(segmentI uint, seg *Segment, inputP, inputQ uint) {
+	m.Record(AcceptEvent, NoReason, segmentI, seg, inputP, inputQ, true)
 	m.C = Capture{
-		InputP:   key.InputI,
-		InputQ:   index,
-		SegmentP: key.SegmentI,
+		InputP:   inputP,
+		InputQ:   inputQ,
+		SegmentP: segmentI,
 		PatternP: seg.PatternP,
 		PatternQ: seg.PatternQ,
 	}
-	m.InputI = index
-	return true
+	m.InputI = inputQ
+}
+
+func (m *Matcher) Reject(reason RejectReason, segmentI uint, seg *Segment, inputP, inputQ uint) {
+	m.Record(RejectEvent, reason, segmentI, seg, inputP, inputQ, false)
+	if m.Failure != nil {
+		m.Failure.Observe(m, reason, segmentI, seg, inputP)
+	}
+	m.Valid = false
 }
 
 func (m *Matcher) Capture() *Capture {
@@ -88,53 +205,151 @@ func (m *Matcher) Matches(g *Glob) bool {
 	return m.OK()
 }
 
+// WouldAccept returns true iff the remaining segments would match the input
+// starting at index i.  The answer depends only on (i, m.SegmentI), so it is
+// memoized alongside the per-segment results.
 func (m *Matcher) WouldAccept(g *Glob, i uint) bool {
+	if m.SegmentI >= m.SegmentJ {
+		return i >= m.InputJ
+	}
+	if !m.Spend() {
+		return false
+	}
+
+	cell := m.Memo.Cell(i, m.SegmentI)
+	flags := m.Memo.Flags[cell]
+	if flags&MemoKnown != 0 {
+		ok := (flags&MemoComplete != 0)
+		if m.Trace != nil {
+			event := &m.Trace.Events[m.Candidate(g, i)]
+			event.Cached = true
+			event.OK = ok
+		}
+		return ok
+	}
+
+	var event int
+	if m.Trace != nil {
+		event = m.Candidate(g, i)
+		m.Trace.Depth++
+	}
+
 	var dupe Matcher
 	dupe = *m
 	dupe.InputI = i
-	return dupe.Matches(g)
+	ok := dupe.Matches(g)
+
+	if m.Trace != nil {
+		m.Trace.Depth--
+		m.Trace.Events[event].OK = ok
+	}
+	if m.Aborted() {
+		return false
+	}
+
+	flags = MemoKnown
+	if ok {
+		flags |= MemoComplete
+	}
+	m.Memo.Flags[cell] |= flags
+	return ok
 }
 
-func (m *Matcher) Tick(g *Glob, seg Segment, moreSegments bool) (uint, bool) {
+// LongestAccepted returns the largest inputJ ∈ [inputI..inputUB] for which
+// WouldAccept is true.  If the next segment is a literal, only the positions
+// where that literal occurs are tested.
+func (m *Matcher) LongestAccepted(g *Glob, inputI, inputUB uint) (uint, bool) {
+	if m.SegmentI < m.SegmentJ && g.Segments[m.SegmentI].Type == LiteralSegment {
+		anchor := g.Segments[m.SegmentI].Literal.Runes
+		n := uint(len(anchor))
+		hi := inputUB
+		for {
+			end := hi + n
+			if end > m.InputJ {
+				end = m.InputJ
+			}
+			k := LastIndexRunes(m.Input.Runes[inputI:end], anchor)
+			if k < 0 {
+				return 0, false
+			}
+			inputJ := inputI + uint(k)
+			if m.WouldAccept(g, inputJ) {
+				return inputJ, true
+			}
+			if inputJ == inputI {
+				return 0, false
+			}
+			hi = inputJ - 1
+		}
+	}
+
+	for inputJ := inputUB; ; inputJ-- {
+		if m.WouldAccept(g, inputJ) {
+			return inputJ, true
+		}
+		if inputJ == inputI {
+			return 0, false
+		}
+	}
+}
+
+func (m *Matcher) Tick(g *Glob, seg Segment, moreSegments bool) (uint, RejectReason) {
 	inputI := m.InputI
 	inputJ := inputI
 	inputL := m.InputJ
 
+	// With HideDotfiles, a '.' which begins a path component may only be
+	// matched by a literal which begins a component of the pattern.
+	hidden := g.Options.HideDotfiles && IsDotfileAt(m.Input.Runes, inputI)
+
 	switch seg.Type {
 	case LiteralSegment:
 		inputJ += uint(len(seg.Literal.Runes))
 		if inputJ > inputL {
-			return 0, false
+			return inputL, EndOfInputReason
 		}
 		runes := m.Input.Runes[inputI:inputJ]
 		if !EqualRunes(seg.Literal.Runes, runes) {
-			return 0, false
+			return inputJ, LiteralMismatchReason
 		}
-		return inputJ, true
+		if hidden && !g.StartsComponent(m.SegmentI-1) {
+			return inputI, DotfileReason
+		}
+		return inputJ, NoReason
 
 	case RuneMatchSegment:
 		inputJ++
 		if inputJ > inputL {
-			return 0, false
+			return inputL, EndOfInputReason
 		}
 		ch := m.Input.Runes[inputI]
 		if !seg.Matcher.MatchRune(ch) {
-			return 0, false
+			return inputJ, RuneClassReason
+		}
+		if hidden {
+			return inputI, DotfileReason
 		}
-		return inputJ, true
+		return inputJ, NoReason
 
 	case QuestionSegment:
 		inputJ++
 		if inputJ > inputL {
-			return 0, false
+			return inputL, EndOfInputReason
 		}
 		ch := m.Input.Runes[inputI]
 		if ch == '/' {
-			return 0, false
+			return inputJ, SeparatorReason
+		}
+		if hidden {
+			return inputI, DotfileReason
 		}
-		return inputJ, true
+		return inputJ, NoReason
 
 	case StarSegment:
+		if hidden {
+			return inputI, DotfileReason
+		}
+
 		// find the next '/'
 		for inputJ < inputL && m.Input.Runes[inputJ] != '/' {
 			inputJ++
@@ -145,60 +360,57 @@ func (m *Matcher) Tick(g *Glob, seg Segment, moreSegments bool) (uint, bool) {
 		// -> -> no '/': accept the rest of the string, no calculations needed
 		// -> -> yes '/': "accept" up to just before the slash, then reject on next tick
 		if !moreSegments {
-			return inputJ, true
+			return inputJ, NoReason
 		}
 
 		// accept string where (length ∈ [0..n]) given n := (inputJ - inputI), longer is better
 		inputUB := inputJ
-		if m.WouldAccept(g, inputJ) {
-			return inputJ, true
-		}
-		for inputJ > inputI {
-			inputJ--
-			if m.WouldAccept(g, inputJ) {
-				return inputJ, true
-			}
+		if inputJ, ok := m.LongestAccepted(g, inputI, inputUB); ok {
+			return inputJ, NoReason
 		}
 
 		// did not find any length which would lead to a match
 		// -> blindly accept the maximum permissible length, then reject on some future tick
-		return inputUB, true
+		return inputUB, NoReason
 
 	case DoubleStarSegment:
 		inputJ = inputL
 
 		// accept empty string
 		if inputI >= inputJ {
-			return inputJ, true
+			return inputJ, NoReason
+		}
+
+		// stop short of any hidden path component
+		if hidden {
+			return inputI, DotfileReason
+		}
+		if g.Options.HideDotfiles {
+			inputJ = VisibleUntil(m.Input.Runes, inputI+1)
 		}
 
 		// no segments after this?
 		// -> accept rest of string, no further calculations needed
 		if !moreSegments {
-			return inputJ, true
+			return inputJ, NoReason
 		}
 
 		// accept string where (length ∈ [0..n]) given n := (inputJ - inputI), longer is better
-		if m.WouldAccept(g, inputJ) {
-			return inputJ, true
-		}
-		for inputJ > inputI {
-			inputJ--
-			if m.WouldAccept(g, inputJ) {
-				return inputJ, true
-			}
+		if inputJ, ok := m.LongestAccepted(g, inputI, inputJ); ok {
+			return inputJ, NoReason
 		}
-		return inputJ, true
+		return inputI, NoReason
 
 	case DoubleStarSlashSegment:
-		// find the last '/'
-		slashes := make(IndexSet, inputL-inputI)
+		// find the last '/', stopping short of any hidden path component
+		limit := inputL
+		if g.Options.HideDotfiles {
+			limit = VisibleUntil(m.Input.Runes, inputI)
+		}
 		inputJ = inputI
-		slashes[inputJ] = true
-		for inputK := inputI; inputK < inputL; inputK++ {
+		for inputK := inputI; inputK < limit; inputK++ {
 			if m.Input.Runes[inputK] == '/' {
 				inputJ = inputK + 1
-				slashes[inputJ] = true
 			}
 		}
 
@@ -207,24 +419,25 @@ func (m *Matcher) Tick(g *Glob, seg Segment, moreSegments bool) (uint, bool) {
 		// -> -> is '/': accept the rest of the string, no calculations needed
 		// -> -> not '/': "accept" the longest permissible string, then reject on next tick
 		if !moreSegments {
-			return inputJ, true
+			return inputJ, NoReason
 		}
 
-		// accept string where [(length ∈ [0..n]) ∧ (inputJ ∈ slashes)] given n := (inputJ - inputI), longer is better
+		// accept string where [(length ∈ [0..n]) ∧ (inputJ follows a '/')] given n := (inputJ - inputI), longer is better
 		inputUB := inputJ
-		if slashes[inputJ] && m.WouldAccept(g, inputJ) {
-			return inputJ, true
-		}
-		for inputJ > inputI {
-			inputJ--
-			if slashes[inputJ] && m.WouldAccept(g, inputJ) {
-				return inputJ, true
+		for {
+			atBoundary := (inputJ == inputI || m.Input.Runes[inputJ-1] == '/')
+			if atBoundary && m.WouldAccept(g, inputJ) {
+				return inputJ, NoReason
+			}
+			if inputJ == inputI {
+				break
 			}
+			inputJ--
 		}
 
 		// did not find any length which would lead to a match
 		// -> blindly accept the maximum permissible length, then reject on some future tick
-		return inputUB, true
+		return inputUB, NoReason
 
 	default:
 		panic(fmt.Errorf("BUG! unknown SegmentType %#v", seg.Type))
@@ -0,0 +1,83 @@
+package guts
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMatcher_AgainstReference(t *testing.T) {
+	patterns := []string{
+		"",
+		"a",
+		"*",
+		"**",
+		"**/",
+		"*/*",
+		"a*b*c",
+		"**/a/**/b",
+		"**/*.c",
+		"a/**/b/**/c",
+		"?*?/**",
+		"[ab]*[^a]**/x",
+		"**a**",
+		"**/**/a",
+	}
+	alphabet := []rune("ab/c.x")
+	rng := rand.New(rand.NewSource(1))
+
+	for _, pattern := range patterns {
+		var g Glob
+		if err := g.Compile(pattern); err != nil {
+			t.Errorf("%q: unexpected error: %v", pattern, err)
+			continue
+		}
+		for n := 0; n < 500; n++ {
+			runes := make([]rune, rng.Intn(10))
+			for i := range runes {
+				runes[i] = alphabet[rng.Intn(len(alphabet))]
+			}
+			input := string(runes)
+
+			var m Matcher
+			g.Matcher(&m, input)
+			actual := m.Matches(&g)
+			expect := referenceMatch(g.Segments, runes)
+			if actual != expect {
+				t.Errorf("%q against %q: expected %v, got %v", input, pattern, expect, actual)
+			}
+		}
+	}
+}
+
+func referenceMatch(segments []Segment, input []rune) bool {
+	if len(segments) == 0 {
+		return len(input) == 0
+	}
+	seg := segments[0]
+	rest := segments[1:]
+	switch seg.Type {
+	case LiteralSegment:
+		n := len(seg.Literal.Runes)
+		return len(input) >= n && EqualRunes(seg.Literal.Runes, input[:n]) && referenceMatch(rest, input[n:])
+	case RuneMatchSegment:
+		return len(input) >= 1 && seg.Matcher.MatchRune(input[0]) && referenceMatch(rest, input[1:])
+	case QuestionSegment:
+		return len(input) >= 1 && input[0] != '/' && referenceMatch(rest, input[1:])
+	}
+	for i := 0; i <= len(input); i++ {
+		switch seg.Type {
+		case StarSegment:
+			if i > 0 && input[i-1] == '/' {
+				return false
+			}
+		case DoubleStarSlashSegment:
+			if i > 0 && input[i-1] != '/' {
+				continue
+			}
+		}
+		if referenceMatch(rest, input[i:]) {
+			return true
+		}
+	}
+	return false
+}
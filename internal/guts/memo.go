@@ -0,0 +1,75 @@
+package guts
+
+// The MemoTable holds one MemoFlags per (input index, segment index) cell,
+// i.e. (InputJ + 1) × SegmentJ cells, plus the end index chosen by each star
+// segment at each input index.  Because every cell is filled in at most once,
+// a match performs at most O(S·n) calls to Tick, each of which tests at most
+// O(n) candidate lengths, for O(S·n·(n+S)) time and O(S·n) space overall,
+// where S is the number of segments and n is the number of input runes.
+const (
+	// MemoVisited is set once Tick has been entered for the cell.
+	MemoVisited MemoFlags = 1 << iota
+
+	// MemoChecked is set once Tick has returned for the cell.
+	MemoChecked
+
+	// MemoRejected is set if Tick rejected the cell.
+	MemoRejected
+
+	// MemoKnown is set once WouldAccept has been computed for the cell.
+	MemoKnown
+
+	// MemoComplete is set if WouldAccept returned true for the cell.
+	MemoComplete
+)
+
+func (t *MemoTable) Reset(inputJ, segmentJ, numStars uint) {
+	numCells := (inputJ + 1) * segmentJ
+	if uint(cap(t.Flags)) >= numCells {
+		t.Flags = t.Flags[:numCells]
+		for i := range t.Flags {
+			t.Flags[i] = 0
+		}
+	} else {
+		t.Flags = make([]MemoFlags, numCells)
+	}
+
+	// Ends are only read after MemoChecked is set, so no need to clear.
+	numEnds := (inputJ + 1) * numStars
+	if uint(cap(t.Ends)) >= numEnds {
+		t.Ends = t.Ends[:numEnds]
+	} else {
+		t.Ends = make([]uint, numEnds)
+	}
+
+	t.Stride = segmentJ
+	t.StarStride = numStars
+}
+
+func (t *MemoTable) Cell(inputI, segmentI uint) uint {
+	return inputI*t.Stride + segmentI
+}
+
+func (t *MemoTable) End(seg *Segment, inputI uint) uint {
+	switch seg.Type {
+	case LiteralSegment:
+		return inputI + uint(len(seg.Literal.Runes))
+	case RuneMatchSegment:
+		fallthrough
+	case QuestionSegment:
+		return inputI + 1
+	default:
+		return t.Ends[inputI*t.StarStride+seg.StarIndex]
+	}
+}
+
+func (t *MemoTable) SetEnd(seg *Segment, inputI, index uint) {
+	switch seg.Type {
+	case StarSegment:
+		fallthrough
+	case DoubleStarSegment:
+		fallthrough
+	case DoubleStarSlashSegment:
+		t.Ends[inputI*t.StarStride+seg.StarIndex] = index
+	}
+}
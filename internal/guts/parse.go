@@ -9,12 +9,26 @@ func (p *Parser) MakeError(format string, args ...interface{}) error {
 	return fmt.Errorf(format, args...)
 }
 
-func (p *Parser) Fail(format string, args ...interface{}) {
+func (p *Parser) Fail(kind ErrorKind, format string, args ...interface{}) {
+	p.FailAt(kind, p.InputQ, format, args...)
+}
+
+func (p *Parser) FailAt(kind ErrorKind, offset uint, format string, args ...interface{}) {
 	if p.Err == nil {
-		p.Err = p.MakeError(format, args...)
+		p.Err = &ParseError{
+			Kind:       kind,
+			Input:      p.Input.String,
+			Message:    fmt.Sprintf(format, args...),
+			RuneOffset: offset,
+			ByteOffset: p.Input.Map[offset],
+		}
 	}
 }
 
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("failed to parse %s: %q: %s", err.Context, err.Input, err.Message)
+}
+
 func (p *Parser) EmitSegment(t SegmentType, PatternP, PatternQ uint) {
 	n := uint(len(p.Segments))
 	p.Segments = append(p.Segments, Segment{
@@ -25,11 +39,15 @@ func (p *Parser) EmitSegment(t SegmentType, PatternP, PatternQ uint) {
 	p.LastSegment = &p.Segments[n]
 }
 
-func (p *Parser) EmitLiteral(ch rune) {
+func (p *Parser) StartLiteral() {
 	if p.PartialLiteral == nil {
 		p.InputP = p.InputQ
 		p.PartialLiteral = takeRuneSlice(0)
 	}
+}
+
+func (p *Parser) EmitLiteral(ch rune) {
+	p.StartLiteral()
 	p.PartialLiteral = append(p.PartialLiteral, ch)
 }
 
@@ -45,7 +63,7 @@ func (p *Parser) EmitSetHi(ch rune) {
 	index := uint(len(p.Ranges)) - 1
 	r := &p.Ranges[index]
 	if ch < r.Lo {
-		p.Fail("invalid range, lo %U > hi %U", r.Lo, ch)
+		p.Fail(InvalidRangeError, "invalid range, lo %U > hi %U", r.Lo, ch)
 	} else {
 		r.Hi = ch
 	}
@@ -56,12 +74,12 @@ func (p *Parser) FlushLiteral() {
 		return
 	}
 
-	str := string(p.PartialLiteral)
+	str := EncodeRawRunes(p.PartialLiteral)
 	giveRuneSlice(p.PartialLiteral)
 	p.PartialLiteral = nil
 
 	p.EmitSegment(LiteralSegment, p.InputP, p.InputQ)
-	p.LastSegment.Literal = Norm(str)
+	p.LastSegment.Literal = Normalize(p.Form, str)
 }
 
 func (p *Parser) FlushSet() {
@@ -72,7 +90,8 @@ func (p *Parser) FlushSet() {
 	p.Ranges = nil
 	p.Negate = false
 
-	p.EmitSegment(RuneMatchSegment, p.InputP, p.InputQ)
+	// The span runs from the '[' to just past the ']', if there is one.
+	p.EmitSegment(RuneMatchSegment, p.SetP, p.InputI)
 	p.LastSegment.Matcher = set
 }
 
@@ -128,7 +147,7 @@ func (p *Parser) ProcessEscape(ch rune, ifOct, ifHex, ifPunct ParseState, emit f
 		emit(0)
 
 	default:
-		p.Fail("invalid escape \\%c", ch)
+		p.FailAt(InvalidEscapeError, p.EscapeP, "invalid escape \\%c", ch)
 	}
 }
 
@@ -137,7 +156,7 @@ func (p *Parser) ProcessOct(ch rune, ifDone ParseState, emit func(rune)) {
 		str := string(p.PartialEscape)
 		giveRuneSlice(p.PartialEscape)
 		p.PartialEscape = nil
-		p.Fail("invalid escape \\%c%s%c", p.EscapeIntroducer, str, ch)
+		p.FailAt(InvalidEscapeError, p.EscapeP, "invalid escape \\%c%s%c", p.EscapeIntroducer, str, ch)
 		return
 	}
 
@@ -163,7 +182,7 @@ func (p *Parser) ProcessHex(ch rune, ifDone ParseState, emit func(rune)) {
 		str := string(p.PartialEscape)
 		giveRuneSlice(p.PartialEscape)
 		p.PartialEscape = nil
-		p.Fail("invalid escape \\%c%s%c", p.EscapeIntroducer, str, ch)
+		p.FailAt(InvalidEscapeError, p.EscapeP, "invalid escape \\%c%s%c", p.EscapeIntroducer, str, ch)
 		return
 	}
 
@@ -195,28 +214,30 @@ func (p *Parser) Run() {
 			switch ch {
 			case '[':
 				p.FlushLiteral()
+				p.SetP = p.InputQ
 				p.State = CharsetInitialState
 
 			case ']':
-				p.Fail("unexpected ']'")
+				p.Fail(UnexpectedRuneError, "unexpected ']'")
 				return
 
 			case '{':
-				p.Fail("alternative matches are not yet implemented: '{'")
+				p.Fail(UnsupportedSyntaxError, "alternative matches are not yet implemented: '{'")
 				return
 
 			case '}':
-				p.Fail("unexpected '}'")
+				p.Fail(UnexpectedRuneError, "unexpected '}'")
 				return
 
 			case '*':
 				p.FlushLiteral()
 				if p.LastSegment != nil && p.LastSegment.Type == DoubleStarSegment {
-					p.Fail("unexpected '***'")
+					p.FailAt(UnexpectedRuneError, p.LastSegment.PatternP, "unexpected '***'")
 					return
 				}
 				if p.LastSegment != nil && p.LastSegment.Type == StarSegment {
 					p.LastSegment.Type = DoubleStarSegment
+					p.LastSegment.PatternQ = p.InputI
 					continue
 				}
 				p.EmitSegment(StarSegment, p.InputQ, p.InputI)
@@ -226,11 +247,17 @@ func (p *Parser) Run() {
 				p.EmitSegment(QuestionSegment, p.InputQ, p.InputI)
 
 			case '\\':
+				// The literal's span includes the backslash.
+				p.EscapeP = p.InputQ
+				p.StartLiteral()
 				p.State = RootEscState
 
 			case '/':
-				if p.LastSegment != nil && p.LastSegment.Type == DoubleStarSegment {
+				// Only "**/" is special, not "**a/", whose literal is
+				// still pending.
+				if p.PartialLiteral == nil && p.LastSegment != nil && p.LastSegment.Type == DoubleStarSegment {
 					p.LastSegment.Type = DoubleStarSlashSegment
+					p.LastSegment.PatternQ = p.InputI
 					continue
 				}
 				fallthrough
@@ -250,18 +277,19 @@ func (p *Parser) Run() {
 		case CharsetInitialState:
 			switch ch {
 			case '[':
-				p.Fail("unexpected '['")
+				p.Fail(UnexpectedRuneError, "unexpected '['")
 				return
 
 			case ']':
 				if p.WantSet {
-					p.Fail("unexpected ']'")
+					p.Fail(UnexpectedRuneError, "unexpected ']'")
 					return
 				}
 				p.FlushSet()
 				p.State = RootState
 
 			case '\\':
+				p.EscapeP = p.InputQ
 				p.State = CharsetHeadEscState
 
 			case '^':
@@ -276,18 +304,19 @@ func (p *Parser) Run() {
 		case CharsetHeadState:
 			switch ch {
 			case '[':
-				p.Fail("unexpected '['")
+				p.Fail(UnexpectedRuneError, "unexpected '['")
 				return
 
 			case ']':
 				if p.WantSet {
-					p.Fail("unexpected ']'")
+					p.Fail(UnexpectedRuneError, "unexpected ']'")
 					return
 				}
 				p.FlushSet()
 				p.State = RootState
 
 			case '\\':
+				p.EscapeP = p.InputQ
 				p.State = CharsetHeadEscState
 
 			default:
@@ -307,18 +336,19 @@ func (p *Parser) Run() {
 		case CharsetMidState:
 			switch ch {
 			case '[':
-				p.Fail("unexpected '['")
+				p.Fail(UnexpectedRuneError, "unexpected '['")
 				return
 
 			case ']':
 				if p.WantSet {
-					p.Fail("unexpected ']'")
+					p.Fail(UnexpectedRuneError, "unexpected ']'")
 					return
 				}
 				p.FlushSet()
 				p.State = RootState
 
 			case '\\':
+				p.EscapeP = p.InputQ
 				p.State = CharsetHeadEscState
 
 			case '-':
@@ -332,12 +362,12 @@ func (p *Parser) Run() {
 		case CharsetTailState:
 			switch ch {
 			case '[':
-				p.Fail("unexpected '['")
+				p.Fail(UnexpectedRuneError, "unexpected '['")
 				return
 
 			case ']':
 				if p.WantSet {
-					p.Fail("unexpected ']'")
+					p.Fail(UnexpectedRuneError, "unexpected ']'")
 					return
 				}
 				p.EmitSetLo('-')
@@ -345,6 +375,7 @@ func (p *Parser) Run() {
 				p.State = RootState
 
 			case '\\':
+				p.EscapeP = p.InputQ
 				p.State = CharsetTailEscState
 
 			default:
@@ -380,21 +411,21 @@ func (p *Parser) Run() {
 		fallthrough
 	case CharsetMidState:
 		if !p.WantSet {
-			p.Fail("unterminated character set")
+			p.FailAt(UnterminatedSetError, p.SetP, "unterminated character set")
 			return
 		}
 		p.FlushSet()
 
 	case CharsetTailState:
 		if !p.WantSet {
-			p.Fail("unterminated character set")
+			p.FailAt(UnterminatedSetError, p.SetP, "unterminated character set")
 			return
 		}
 		p.EmitSetLo('-')
 		p.FlushSet()
 
 	default:
-		p.Fail("unterminated backslash escape")
+		p.FailAt(UnterminatedEscapeError, p.EscapeP, "unterminated backslash escape")
 		return
 	}
 
@@ -425,6 +456,8 @@ func (p *Parser) Run() {
 		case DoubleStarSlashSegment:
 			segMin = 0
 			segMax = UintMax
+			seg.StarIndex = p.NumStars
+			p.NumStars++
 
 		default:
 			panic(fmt.Errorf("BUG! unknown SegmentType %#v", seg.Type))
@@ -443,3 +476,45 @@ func (p *Parser) Run() {
 	p.MinLength = min
 	p.MaxLength = max
 }
+
+func (p *Parser) CheckLimits(limits Limits) {
+	if p.Err != nil {
+		return
+	}
+
+	numSegments := uint(len(p.Segments))
+	if max := limits.MaxSegments; max != 0 && numSegments > max {
+		seg := &p.Segments[max]
+		p.FailAt(LimitError, seg.PatternP, "pattern has more than the limit of %d segments", max)
+		return
+	}
+
+	numWildcards := uint(0)
+	for i := uint(0); i < numSegments; i++ {
+		seg := &p.Segments[i]
+		switch seg.Type {
+		case QuestionSegment:
+			fallthrough
+		case StarSegment:
+			fallthrough
+		case DoubleStarSegment:
+			fallthrough
+		case DoubleStarSlashSegment:
+			numWildcards++
+			if max := limits.MaxWildcards; max != 0 && numWildcards > max {
+				p.FailAt(LimitError, seg.PatternP, "pattern has more than the limit of %d wildcards", max)
+				return
+			}
+
+		case RuneMatchSegment:
+			numRanges := uint(0)
+			seg.Matcher.ForEachRange(func(lo, hi rune) {
+				numRanges++
+			})
+			if max := limits.MaxSetRanges; max != 0 && numRanges > max {
+				p.FailAt(LimitError, seg.PatternP, "character set has more than the limit of %d ranges", max)
+				return
+			}
+		}
+	}
+}
@@ -95,6 +95,15 @@ func TestCompile(t *testing.T) {
 				expectRuneMatchSegment(7, (*SetMatch)(nil), chSet, asSet),
 			},
 		},
+		{
+			Name:              "DoubleStarThenLiteral",
+			Pattern:           "**ab/c",
+			ExpectNumSegments: 2,
+			Expectations: []globCompileExpectation{
+				expectSpecialSegment(0, DoubleStarSegment),
+				expectLiteralSegment(1, "ab/c"),
+			},
+		},
 	}
 
 	for _, row := range testdata {
@@ -0,0 +1,67 @@
+package guts
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func IsNormStable(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return true
+	}
+	str := string(ch)
+	return norm.NFC.IsNormalString(str) &&
+		norm.NFD.IsNormalString(str) &&
+		norm.NFKC.IsNormalString(str) &&
+		norm.NFKD.IsNormalString(str)
+}
+
+func AppendClassRune(runes []rune, ch rune) []rune {
+	// Characters inside a set are matched one rune at a time, so any
+	// rune that normalization would rewrite must be spelled as an escape.
+	if IsNormStable(ch) {
+		return SafeAppendRune(runes, ch)
+	}
+	if ch < 0x10000 {
+		return appendHexEscape(runes, 'u', uint32(ch), 4)
+	}
+	return appendHexEscape(runes, 'U', uint32(ch), 8)
+}
+
+func appendHexEscape(runes []rune, introducer rune, value uint32, digits uint) []rune {
+	const hexDigits = "0123456789abcdef"
+	runes = append(runes, '\\', introducer)
+	for digits > 0 {
+		digits--
+		runes = append(runes, rune(hexDigits[(value>>(4*digits))&0xf]))
+	}
+	return runes
+}
+
+func AppendRuneMatcher(runes []rune, m RuneMatcher) []rune {
+	// Sets which reach the top of the Unicode range are almost always
+	// written as negations, so render them that way.
+	ranges := Ranges(m)
+	n := len(ranges)
+	negate := (n > 0 && ranges[n-1].Hi == unicode.MaxRune)
+	if negate {
+		ranges = Ranges(m.Not())
+	}
+
+	runes = append(runes, '[')
+	if negate {
+		runes = append(runes, '^')
+	}
+	for _, r := range ranges {
+		runes = AppendClassRune(runes, r.Lo)
+		if r.Hi > r.Lo {
+			if r.Hi > r.Lo+1 {
+				runes = append(runes, '-')
+			}
+			runes = AppendClassRune(runes, r.Hi)
+		}
+	}
+	return append(runes, ']')
+}
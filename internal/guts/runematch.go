@@ -24,7 +24,8 @@ func CompileRuneMatcher(input string) (RuneMatcher, error) {
 	p.Run()
 
 	if p.Err != nil {
-		return nil, fmt.Errorf("failed to parse character set: %q: %v", p.Input.String, p.Err)
+		p.Err.Context = "character set"
+		return nil, p.Err
 	}
 	if len(p.Segments) != 1 {
 		panic(fmt.Errorf("BUG! expected 1 RuneMatchSegment, got %d segments", len(p.Segments)))
@@ -70,3 +71,60 @@ func Set(matchers ...RuneMatcher) RuneMatcher {
 	}
 	return BuildSet(ranges)
 }
+
+func Ranges(m RuneMatcher) SortedLoHi {
+	out := make(SortedLoHi, 0, 8)
+	m.ForEachRange(func(lo, hi rune) {
+		out = append(out, LoHi{Lo: lo, Hi: hi})
+	})
+	return out
+}
+
+func Union(matchers ...RuneMatcher) RuneMatcher {
+	return Set(matchers...)
+}
+
+func Intersect(a, b RuneMatcher) RuneMatcher {
+	ra := Ranges(a)
+	rb := Ranges(b)
+	out := make([]LoHi, 0, len(ra)+len(rb))
+
+	// Both lists are sorted and non-overlapping, so sweep them together.
+	i, j := 0, 0
+	for i < len(ra) && j < len(rb) {
+		lo, hi := ra[i].Lo, ra[i].Hi
+		if rb[j].Lo > lo {
+			lo = rb[j].Lo
+		}
+		if rb[j].Hi < hi {
+			hi = rb[j].Hi
+		}
+		if lo <= hi {
+			out = append(out, LoHi{Lo: lo, Hi: hi})
+		}
+		if ra[i].Hi < rb[j].Hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return BuildSet(out)
+}
+
+func Subtract(a, b RuneMatcher) RuneMatcher {
+	return Intersect(a, b.Not())
+}
+
+func EqualMatchers(a, b RuneMatcher) bool {
+	ra := Ranges(a)
+	rb := Ranges(b)
+	if len(ra) != len(rb) {
+		return false
+	}
+	for i := range ra {
+		if ra[i] != rb[i] {
+			return false
+		}
+	}
+	return true
+}
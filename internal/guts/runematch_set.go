@@ -121,7 +121,7 @@ func BuildSet(ranges []LoHi) RuneMatcher {
 			out = append(out, r)
 			prevIdx++
 			prevPtr = &out[prevIdx]
-		} else {
+		} else if r.Hi > prevPtr.Hi {
 			prevPtr.Hi = r.Hi
 		}
 	}
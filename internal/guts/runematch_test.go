@@ -41,6 +41,13 @@ func TestMatchers(t *testing.T) {
 			ExpectAccept: []rune{0, '/', ':', 'A', 'a', unicode.MaxRune},
 			ExpectReject: []rune{'0', '9'},
 		},
+		{
+			Name:         "Set [a-z/m]",
+			Value:        BuildSet([]LoHi{{'a', 'z'}, {'/', '/'}, {'m', 'm'}}),
+			ExpectRanges: []RangeMatch{{'/', '/'}, {'a', 'z'}},
+			ExpectAccept: []rune{'/', 'a', 'm', 'n', 'z'},
+			ExpectReject: []rune{0, '0', 'A', unicode.MaxRune},
+		},
 	}
 	for _, row := range testdata {
 		t.Run(row.Name, func(t *testing.T) {
@@ -0,0 +1,30 @@
+package guts
+
+func (m *Matcher) Record(t TraceEventType, reason RejectReason, segmentI uint, seg *Segment, inputP, inputQ uint, ok bool) {
+	if m.Trace == nil {
+		return
+	}
+	event := TraceEvent{
+		Type:     t,
+		Reason:   reason,
+		OK:       ok,
+		Depth:    m.Trace.Depth,
+		SegmentI: segmentI,
+		InputP:   inputP,
+		InputQ:   inputQ,
+	}
+	if seg != nil {
+		event.PatternP = seg.PatternP
+		event.PatternQ = seg.PatternQ
+	}
+	m.Trace.Events = append(m.Trace.Events, event)
+}
+
+// Candidate records that the current segment is being tested against the
+// input from m.InputI to i, and returns the index of the event so that the
+// outcome can be filled in once nested events have been recorded.
+func (m *Matcher) Candidate(g *Glob, i uint) int {
+	segmentI := m.SegmentI - 1
+	m.Record(CandidateEvent, NoReason, segmentI, &g.Segments[segmentI], m.InputI, i, false)
+	return len(m.Trace.Events) - 1
+}
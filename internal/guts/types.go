@@ -1,6 +1,7 @@
 package guts
 
 import (
+	"context"
 	"fmt"
 	"sort"
 )
@@ -37,16 +38,14 @@ type ExceptSetMatch struct {
 	Ranges SortedLoHi
 }
 
-type MemoMap map[MemoKey]*MemoValue
-type MemoKey struct{ InputI, SegmentI uint }
-type MemoValue struct {
-	Checked  bool
-	Rejected bool
-	Index    uint
+type MemoFlags uint8
+type MemoTable struct {
+	Flags      []MemoFlags
+	Ends       []uint
+	Stride     uint
+	StarStride uint
 }
 
-type IndexSet map[uint]bool
-
 type SegmentType byte
 
 type Segment struct {
@@ -57,23 +56,83 @@ type Segment struct {
 	PatternQ  uint
 	MinLength uint
 	MaxLength uint
+	StarIndex uint
+}
+
+type NormForm byte
+
+type Limits struct {
+	MaxPatternLength uint
+	MaxSegments      uint
+	MaxWildcards     uint
+	MaxSetRanges     uint
+	MaxExpansion     uint
+	MaxSteps         uint64
+}
+
+type Options struct {
+	Form         NormForm
+	Limits       Limits
+	HideDotfiles bool
+}
+
+type Budget struct {
+	Context  context.Context
+	Err      error
+	Steps    uint64
+	MaxSteps uint64
 }
 
 type Glob struct {
+	Options   Options
 	Pattern   ExplodedString
 	Segments  []Segment
+	Prefix    []rune
+	Suffix    []rune
 	MinLength uint
 	MaxLength uint
+	NumStars  uint
+}
+
+type TraceEventType byte
+type RejectReason byte
+type TraceEvent struct {
+	Type     TraceEventType
+	Reason   RejectReason
+	Cached   bool
+	OK       bool
+	Depth    uint
+	SegmentI uint
+	PatternP uint
+	PatternQ uint
+	InputP   uint
+	InputQ   uint
+}
+type Trace struct {
+	Events []TraceEvent
+	Depth  uint
+}
+
+type Failure struct {
+	Found    bool
+	Reason   RejectReason
+	SegmentI uint
+	InputP   uint
+	InputI   uint
 }
 
 type Matcher struct {
-	Memo     MemoMap
+	Memo     MemoTable
+	Budget   *Budget
+	Trace    *Trace
+	Failure  *Failure
 	Input    ExplodedString
 	C        Capture
 	InputI   uint
 	InputJ   uint
 	SegmentI uint
 	SegmentJ uint
+	Form     NormForm
 	Valid    bool
 }
 
@@ -85,6 +144,16 @@ type Capture struct {
 	PatternQ uint
 }
 
+type ErrorKind byte
+type ParseError struct {
+	Kind       ErrorKind
+	Context    string
+	Input      string
+	Message    string
+	RuneOffset uint
+	ByteOffset uint
+}
+
 type ParseState byte
 type Parser struct {
 	Input            ExplodedString
@@ -93,14 +162,18 @@ type Parser struct {
 	PartialLiteral   []rune
 	PartialEscape    []rune
 	LastSegment      *Segment
-	Err              error
+	Err              *ParseError
 	InputP           uint
 	InputQ           uint
 	InputI           uint
 	InputJ           uint
+	SetP             uint
+	EscapeP          uint
 	MinLength        uint
 	MaxLength        uint
+	NumStars         uint
 	EscapeIntroducer rune
+	Form             NormForm
 	State            ParseState
 	EscapeLen        byte
 	Negate           bool
@@ -116,7 +189,16 @@ var _ RuneMatcher = (*ExceptRangeMatch)(nil)
 var _ RuneMatcher = (*SetMatch)(nil)
 var _ RuneMatcher = (*ExceptSetMatch)(nil)
 var _ sort.Interface = SortedLoHi(nil)
+var _ error = (*ParseError)(nil)
 var _ fmt.Stringer = SegmentType(0)
 var _ fmt.Stringer = ParseState(0)
+var _ fmt.Stringer = ErrorKind(0)
+var _ fmt.Stringer = NormForm(0)
+var _ fmt.Stringer = TraceEventType(0)
+var _ fmt.Stringer = RejectReason(0)
 var _ fmt.GoStringer = SegmentType(0)
 var _ fmt.GoStringer = ParseState(0)
+var _ fmt.GoStringer = ErrorKind(0)
+var _ fmt.GoStringer = NormForm(0)
+var _ fmt.GoStringer = TraceEventType(0)
+var _ fmt.GoStringer = RejectReason(0)
@@ -4,41 +4,95 @@ import (
 	"fmt"
 	"unicode"
 	"unicode/utf8"
-
-	"golang.org/x/text/unicode/norm"
 )
 
 func Norm(in string) ExplodedString {
+	return Normalize(NFKCNorm, in)
+}
+
+func Normalize(form NormForm, in string) ExplodedString {
 	var out ExplodedString
+	NormalizeInto(&out, form, in)
+	return out
+}
 
-	tmp0 := takeByteSlice(uint(len(in)))
-	defer giveByteSlice(tmp0)
+// NormalizeInto is like Normalize, but reuses the Runes and Map slices of
+// out when they have enough capacity.
+func NormalizeInto(out *ExplodedString, form NormForm, in string) {
+	if form == NoNorm || form.Form().IsNormalString(in) {
+		// out.String <- the input itself; strings are immutable, so no copy is needed
+		out.String = in
+	} else {
+		tmp0 := takeByteSlice(uint(len(in)))
+		defer giveByteSlice(tmp0)
 
-	// tmp0 <- normalized UTF-8 bytes
-	tmp0 = norm.NFKC.AppendString(tmp0, in)
+		// tmp0 <- normalized UTF-8 bytes
+		tmp0 = form.Form().AppendString(tmp0, in)
 
-	// out.String <- copy tmp0 UTF-8 bytes to new UTF-8 string
-	out.String = string(tmp0)
+		// out.String <- copy tmp0 UTF-8 bytes to new UTF-8 string
+		out.String = string(tmp0)
+	}
 
 	// numRunes <- count # of runes in UTF-8 string
 	numRunes := uint(utf8.RuneCountInString(out.String))
 
-	tmp1 := takeRuneSlice(numRunes)
-	defer giveRuneSlice(tmp1)
-
-	// tmp1 <- copy UTF-8 runes to new slice of UTF-32 runes
+	// out.Runes <- UTF-32 runes of UTF-8 string
 	// out.Map <- map from <UTF-32 offset> to <UTF-8 offset>
-	out.Map = make([]uint, 0, numRunes+1)
-	for bi, ch := range out.String {
-		tmp1 = append(tmp1, ch)
-		out.Map = append(out.Map, uint(bi))
+	runes := out.Runes[:0]
+	if uint(cap(runes)) < numRunes {
+		runes = make([]rune, 0, numRunes)
+	}
+	offsets := out.Map[:0]
+	if uint(cap(offsets)) < numRunes+1 {
+		offsets = make([]uint, 0, numRunes+1)
 	}
-	out.Map = append(out.Map, uint(len(out.String)))
+	for bi := 0; bi < len(out.String); {
+		ch, size := DecodeRawRune(out.String[bi:])
+		runes = append(runes, ch)
+		offsets = append(offsets, uint(bi))
+		bi += size
+	}
+	offsets = append(offsets, uint(len(out.String)))
 
-	// out.Runes <- permanent copy of tmp1
-	out.Runes = copyRunes(tmp1)
+	out.Runes = runes
+	out.Map = offsets
+}
 
-	return out
+// DecodeRawRune is like utf8.DecodeRuneInString, except that each byte of an
+// invalid UTF-8 sequence decodes to its own rune in the range U+DC80..U+DCFF.
+// Such lone surrogates can never be decoded from valid UTF-8, so no two
+// distinct byte strings ever decode to the same runes.
+func DecodeRawRune(s string) (rune, int) {
+	ch, size := utf8.DecodeRuneInString(s)
+	if ch == utf8.RuneError && size == 1 {
+		ch = RawByteRune(s[0])
+	}
+	return ch, size
+}
+
+func RawByteRune(b byte) rune {
+	return RawByteLo | rune(b&0x7f)
+}
+
+func IsRawByteRune(ch rune) bool {
+	return ch >= RawByteLo && ch <= RawByteHi
+}
+
+// EncodeRawRunes is the inverse of DecodeRawRune.
+func EncodeRawRunes(runes []rune) string {
+	tmp := takeByteSlice(uint(len(runes)))
+	defer giveByteSlice(tmp)
+
+	for _, ch := range runes {
+		if IsRawByteRune(ch) {
+			tmp = append(tmp, byte(ch-RawByteLo)|0x80)
+			continue
+		}
+		var buf [utf8.UTFMax]byte
+		n := utf8.EncodeRune(buf[:], ch)
+		tmp = append(tmp, buf[:n]...)
+	}
+	return string(tmp)
 }
 
 func (x ExplodedString) Substring(i, j uint) string {
@@ -114,6 +168,55 @@ func DenseBit(ch rune) uint64 {
 	return uint64(1) << shift
 }
 
+// IsDotfileAt returns true iff runes[i] is a '.' at the start of a path
+// component.
+func IsDotfileAt(runes []rune, i uint) bool {
+	return i < uint(len(runes)) && runes[i] == '.' && (i == 0 || runes[i-1] == '/')
+}
+
+// VisibleUntil returns the offset of the first '.' at the start of a path
+// component at or after i, or len(runes) if there is none.
+func VisibleUntil(runes []rune, i uint) uint {
+	n := uint(len(runes))
+	for ; i < n; i++ {
+		if IsDotfileAt(runes, i) {
+			return i
+		}
+	}
+	return n
+}
+
+func HasPrefixRunes(runes, prefix []rune) bool {
+	return len(runes) >= len(prefix) && sameRunes(runes[:len(prefix)], prefix)
+}
+
+func HasSuffixRunes(runes, suffix []rune) bool {
+	return len(runes) >= len(suffix) && sameRunes(runes[len(runes)-len(suffix):], suffix)
+}
+
+func LastIndexRunes(haystack, needle []rune) int {
+	n := len(needle)
+	for i := len(haystack) - n; i >= 0; i-- {
+		if sameRunes(haystack[i:i+n], needle) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameRunes is like EqualRunes, but does not distinguish nil from empty.
+func sameRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func EqualRunes(a, b []rune) bool {
 	if a == nil || b == nil {
 		return (a == nil && b == nil)
@@ -0,0 +1,92 @@
+package glob
+
+import (
+	"context"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// ErrStepLimit is returned by Matcher.Err when a match is abandoned because it
+// exceeded Limits.MaxSteps.
+var ErrStepLimit = guts.ErrStepLimit
+
+// Limits bounds the resources which an untrusted pattern may consume.  A zero
+// field means no limit.  Compile rejects patterns which exceed the
+// compile-time limits with a *SyntaxError of kind LimitError.
+type Limits struct {
+	// MaxPatternLength limits the length of the pattern, in runes.
+	MaxPatternLength uint
+
+	// MaxSegments limits the number of segments the pattern compiles to.
+	MaxSegments uint
+
+	// MaxWildcards limits the total number of "?", "*", "**" and "**/".
+	MaxWildcards uint
+
+	// MaxSetRanges limits the number of disjoint ranges in each character
+	// set, which bounds the cost of matching one rune against it.
+	MaxSetRanges uint
+
+	// MaxExpansion limits the number of patterns which a pattern with
+	// braces may expand to.  See Dialect.
+	MaxExpansion uint
+
+	// MaxSteps limits the work done by each Matcher.  Once exceeded,
+	// HasNext returns false and Err returns ErrStepLimit.
+	MaxSteps uint64
+}
+
+// WithLimits applies resource limits to the pattern and its matchers.
+func WithLimits(limits Limits) Option {
+	return func(opts *options) {
+		opts.impl.Limits = guts.Limits{
+			MaxPatternLength: limits.MaxPatternLength,
+			MaxSegments:      limits.MaxSegments,
+			MaxWildcards:     limits.MaxWildcards,
+			MaxSetRanges:     limits.MaxSetRanges,
+			MaxExpansion:     limits.MaxExpansion,
+			MaxSteps:         limits.MaxSteps,
+		}
+	}
+}
+
+// MatcherContext is like Matcher, but the Matcher gives up once ctx is done.
+func (g *Glob) MatcherContext(ctx context.Context, input string) *Matcher {
+	m := g.Matcher(input)
+	m.SetContext(ctx)
+	return m
+}
+
+// SetContext makes the Matcher give up once ctx is done.  HasNext then
+// returns false, and Err returns ctx.Err().
+func (m *Matcher) SetContext(ctx context.Context) {
+	if m.x != nil {
+		for _, sub := range m.x.subs {
+			sub.SetContext(ctx)
+		}
+		return
+	}
+	m.budget.Context = ctx
+	m.impl.Budget = m.budgetPtr()
+}
+
+// SetMaxSteps overrides Limits.MaxSteps for this Matcher; zero means no
+// limit.  The count of steps taken is reset by Reset.
+func (m *Matcher) SetMaxSteps(max uint64) {
+	if m.x != nil {
+		for _, sub := range m.x.subs {
+			sub.SetMaxSteps(max)
+		}
+		return
+	}
+	m.budget.MaxSteps = max
+	m.impl.Budget = m.budgetPtr()
+}
+
+// Err returns the reason the Matcher gave up early, or nil if it did not.
+func (m *Matcher) Err() error {
+	if m.x != nil {
+		return m.x.err()
+	}
+	return m.impl.Err()
+}
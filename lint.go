@@ -0,0 +1,244 @@
+package glob
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// WarningKind classifies a Warning returned by Lint.
+type WarningKind byte
+
+const (
+	// SyntaxWarning indicates that the pattern does not compile at all.
+	SyntaxWarning WarningKind = iota
+
+	// DoubleStarBoundaryWarning indicates a "**" which is not a whole
+	// path component, such as "a**b".  It behaves like "*" except that it
+	// also crosses '/', which is rarely what was intended.
+	DoubleStarBoundaryWarning
+
+	// EmptySetWarning indicates a character set which contains no runes,
+	// so the pattern can never match anything.
+	EmptySetWarning
+
+	// PunctuationRangeWarning indicates a character range between two
+	// alphanumerics which also covers punctuation, such as "[A-z]".
+	PunctuationRangeWarning
+
+	// RedundantDoubleStarWarning indicates consecutive "**" components,
+	// such as "**/**/", which mean the same as one.
+	RedundantDoubleStarWarning
+
+	// TrailingSeparatorWarning indicates a pattern which ends in '/', and
+	// therefore only matches paths which also end in '/'.
+	TrailingSeparatorWarning
+
+	// NormalizationWarning indicates a pattern which is changed by Unicode
+	// normalization, so it will also match strings that look different.
+	NormalizationWarning
+)
+
+var warningKindNames = []string{
+	"SyntaxWarning",
+	"DoubleStarBoundaryWarning",
+	"EmptySetWarning",
+	"PunctuationRangeWarning",
+	"RedundantDoubleStarWarning",
+	"TrailingSeparatorWarning",
+	"NormalizationWarning",
+}
+
+func (x WarningKind) String() string {
+	if uint(x) >= uint(len(warningKindNames)) {
+		return fmt.Sprintf("%%!WarningKind(%d)", x)
+	}
+	return warningKindNames[x]
+}
+
+func (x WarningKind) GoString() string {
+	if uint(x) >= uint(len(warningKindNames)) {
+		return fmt.Sprintf("WarningKind(%d)", x)
+	}
+	return warningKindNames[x]
+}
+
+// Warning describes a suspicious construct found by Lint.  PatternP and
+// PatternQ delimit the offending runes of Pattern, the native pattern which
+// was checked; see Lint.
+type Warning struct {
+	Kind     WarningKind
+	Pattern  string
+	PatternP uint
+	PatternQ uint
+	Message  string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%d:%d: %s", w.PatternP, w.PatternQ, w.Message)
+}
+
+// Lint checks a pattern for constructs which are legal but probably
+// mistaken.  It returns nil if the pattern looks fine.
+//
+// With WithDialect, the pattern is first translated into native syntax, and
+// each native pattern which it expands to is checked separately, so Pattern
+// may differ from one Warning to the next.  The offsets of a SyntaxWarning
+// or a NormalizationWarning are always relative to the pattern as written,
+// after normalization.  A DoubleStarBoundaryWarning is only reported for
+// dialects with DoubleStarAnywhere, since otherwise every "**" in the
+// translation was put there deliberately.
+func Lint(pattern string, opts ...Option) []Warning {
+	o := buildOptions(opts)
+	if o.dialect.isNative() {
+		var g guts.Glob
+		if err := g.CompileWith(pattern, o.impl); err != nil {
+			perr := err.(*guts.ParseError)
+			return []Warning{{
+				Kind:     SyntaxWarning,
+				Pattern:  perr.Input,
+				PatternP: perr.RuneOffset,
+				PatternQ: perr.RuneOffset,
+				Message:  perr.Message,
+			}}
+		}
+		out := lintNormalization(pattern, g.Pattern)
+		return lintSegments(out, &g, true)
+	}
+
+	g, err := compileDialect(pattern, o)
+	if err != nil {
+		serr := err.(*SyntaxError)
+		return []Warning{{
+			Kind:     SyntaxWarning,
+			Pattern:  serr.Pattern,
+			PatternP: serr.RuneOffset,
+			PatternQ: serr.RuneOffset,
+			Message:  serr.Message,
+		}}
+	}
+	out := lintNormalization(pattern, guts.Normalize(o.impl.Form, pattern))
+	doubleStar := o.dialect.DoubleStar == DoubleStarAnywhere && o.dialect.Separator != OrdinarySeparator
+	for _, leaf := range g.leaves(nil) {
+		out = lintSegments(out, &leaf.impl, doubleStar)
+	}
+	return out
+}
+
+func lintNormalization(pattern string, normalized guts.ExplodedString) []Warning {
+	if normalized.String == pattern {
+		return nil
+	}
+	return []Warning{{
+		Kind:     NormalizationWarning,
+		Pattern:  normalized.String,
+		PatternP: 0,
+		PatternQ: uint(len(normalized.Runes)),
+		Message:  fmt.Sprintf("pattern %q is normalized to %q", pattern, normalized.String),
+	}}
+}
+
+// lintSegments appends the warnings for a compiled native pattern.  A "**"
+// which is not a whole path component is reported only if doubleStar is
+// true.
+func lintSegments(out []Warning, g *guts.Glob, doubleStar bool) []Warning {
+	warn := func(kind WarningKind, p, q uint, format string, args ...interface{}) {
+		out = append(out, Warning{
+			Kind:     kind,
+			Pattern:  g.Pattern.String,
+			PatternP: p,
+			PatternQ: q,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	segments := g.Segments
+	n := len(segments)
+	for i := range segments {
+		seg := &segments[i]
+		var prev, next *guts.Segment
+		if i > 0 {
+			prev = &segments[i-1]
+		}
+		if i+1 < n {
+			next = &segments[i+1]
+		}
+
+		switch seg.Type {
+		case guts.DoubleStarSegment, guts.DoubleStarSlashSegment:
+			startsComponent := (prev == nil || endsWithSlash(prev))
+			endsComponent := (seg.Type == guts.DoubleStarSlashSegment || next == nil)
+			if doubleStar && (!startsComponent || !endsComponent) {
+				warn(DoubleStarBoundaryWarning, seg.PatternP, seg.PatternQ,
+					"'**' is not a whole path component; it behaves like '*' that also matches '/'")
+			}
+			if prev != nil && prev.Type == guts.DoubleStarSlashSegment {
+				warn(RedundantDoubleStarWarning, prev.PatternP, seg.PatternQ,
+					"consecutive '**' components are redundant")
+			}
+
+		case guts.RuneMatchSegment:
+			if _, ok := seg.Matcher.(*guts.NoneMatch); ok {
+				warn(EmptySetWarning, seg.PatternP, seg.PatternQ,
+					"empty character set can never match")
+				continue
+			}
+			for _, r := range positiveRanges(seg.Matcher) {
+				if spansPunctuation(r.Lo, r.Hi) {
+					warn(PunctuationRangeWarning, seg.PatternP, seg.PatternQ,
+						"range %q-%q also matches punctuation", r.Lo, r.Hi)
+				}
+			}
+		}
+	}
+
+	if n > 0 && endsWithSlash(&segments[n-1]) {
+		last := &segments[n-1]
+		warn(TrailingSeparatorWarning, last.PatternQ-1, last.PatternQ,
+			"trailing '/' only matches paths which also end in '/'")
+	}
+
+	return out
+}
+
+func endsWithSlash(seg *guts.Segment) bool {
+	switch seg.Type {
+	case guts.DoubleStarSlashSegment:
+		return true
+	case guts.LiteralSegment:
+		runes := seg.Literal.Runes
+		return len(runes) > 0 && runes[len(runes)-1] == '/'
+	default:
+		return false
+	}
+}
+
+// positiveRanges returns the ranges as written in the pattern, i.e. without
+// the complement implied by a leading '^'.
+func positiveRanges(m guts.RuneMatcher) guts.SortedLoHi {
+	switch m.(type) {
+	case *guts.IsNotMatch, *guts.ExceptRangeMatch, *guts.ExceptSetMatch:
+		m = m.Not()
+	}
+	return guts.Ranges(m)
+}
+
+func spansPunctuation(lo, hi rune) bool {
+	isAlnum := func(ch rune) bool {
+		return unicode.IsLetter(ch) || unicode.IsDigit(ch)
+	}
+	if !isAlnum(lo) || !isAlnum(hi) || hi > unicode.MaxASCII {
+		return false
+	}
+	for ch := lo; ch <= hi; ch++ {
+		if !isAlnum(ch) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ fmt.Stringer = WarningKind(0)
+var _ fmt.GoStringer = WarningKind(0)
+var _ fmt.Stringer = Warning{}
@@ -0,0 +1,81 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	type testrow struct {
+		Pattern string
+		Expect  []WarningKind
+	}
+	testdata := []testrow{
+		{"src/**/*.go", nil},
+		{"**", nil},
+		{"a/**", nil},
+		{"[A-Za-z0-9_]", nil},
+		{"a**b", []WarningKind{DoubleStarBoundaryWarning}},
+		{"a**/b", []WarningKind{DoubleStarBoundaryWarning}},
+		{"x[]", []WarningKind{EmptySetWarning}},
+		{"[A-z]", []WarningKind{PunctuationRangeWarning}},
+		{"[^0-z]", []WarningKind{PunctuationRangeWarning}},
+		{"**/**/x", []WarningKind{RedundantDoubleStarWarning}},
+		{"a/**/**", []WarningKind{RedundantDoubleStarWarning}},
+		{"dir/", []WarningKind{TrailingSeparatorWarning}},
+		{"dir/**/", []WarningKind{TrailingSeparatorWarning}},
+		{"ﬁle", []WarningKind{NormalizationWarning}},
+		{"[a", []WarningKind{SyntaxWarning}},
+	}
+	for _, row := range testdata {
+		t.Run(row.Pattern, func(t *testing.T) {
+			warnings := Lint(row.Pattern)
+			if len(warnings) != len(row.Expect) {
+				t.Errorf("expected %d warnings, got %v", len(row.Expect), warnings)
+				return
+			}
+			for i, w := range warnings {
+				if w.Kind != row.Expect[i] {
+					t.Errorf("warning %d: expected %#v, got %#v: %v", i, row.Expect[i], w.Kind, w)
+				}
+			}
+		})
+	}
+}
+
+func TestLint_Dialect(t *testing.T) {
+	type testrow struct {
+		Dialect Dialect
+		Pattern string
+		Expect  []WarningKind
+	}
+	testdata := []testrow{
+		{Bash, "[!a]", nil},
+		{Bash, "*.{c,h}", nil},
+		{Gitignore, "[!a]*.o", nil},
+		{Gitignore, "a**b", nil},
+		{Gitignore, "**/**/x", []WarningKind{RedundantDoubleStarWarning}},
+		{POSIX, "a*b?", nil},
+		{EditorConfig, "{[A-z],b}", []WarningKind{PunctuationRangeWarning}},
+		{EditorConfig, "a***b", []WarningKind{DoubleStarBoundaryWarning}},
+		{Zsh, "a[b", []WarningKind{SyntaxWarning}},
+	}
+	for _, row := range testdata {
+		t.Run(row.Dialect.Name+"/"+row.Pattern, func(t *testing.T) {
+			warnings := Lint(row.Pattern, WithDialect(row.Dialect))
+			if len(warnings) != len(row.Expect) {
+				t.Errorf("expected %d warnings, got %v", len(row.Expect), warnings)
+				return
+			}
+			for i, w := range warnings {
+				if w.Kind != row.Expect[i] {
+					t.Errorf("warning %d: expected %#v, got %#v: %v", i, row.Expect[i], w.Kind, w)
+				}
+			}
+		})
+	}
+
+	w := Lint("a[b", WithDialect(Zsh))
+	if len(w) != 1 || w[0].PatternP != 1 || w[0].Pattern != "a[b" {
+		t.Errorf("expected a SyntaxWarning at 1 in %q, got %#v", "a[b", w)
+	}
+}
@@ -0,0 +1,94 @@
+package glob
+
+import (
+	"fmt"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// Option configures how Compile parses a pattern and how the resulting Glob
+// matches input.
+type Option func(*options)
+
+type options struct {
+	impl    guts.Options
+	dialect Dialect
+}
+
+func buildOptions(opts []Option) options {
+	var out options
+	for _, opt := range opts {
+		opt(&out)
+	}
+	return out
+}
+
+// withOptions reproduces the options of an existing Glob.
+func withOptions(impl guts.Options) Option {
+	return func(opts *options) {
+		opts.impl = impl
+	}
+}
+
+// Normalization selects the Unicode normalization form which is applied to
+// both the pattern and every input before matching.
+type Normalization byte
+
+const (
+	// NFKC applies compatibility composition.  It folds look-alike
+	// characters, e.g. "ﬁ" matches "fi" and full-width digits match ASCII
+	// digits.  This is the default.
+	NFKC Normalization = Normalization(guts.NFKCNorm)
+
+	// NFC applies canonical composition.
+	NFC Normalization = Normalization(guts.NFCNorm)
+
+	// NFD is for names in canonical decomposition, as used by macOS
+	// HFS+.  It matches exactly the same strings as NFC: the pattern and
+	// the input are composed before matching, so that a character set or
+	// '?' matches "é" whether it is written as one rune or two, and the
+	// text reported by a Glob or Matcher is composed too.
+	NFD Normalization = Normalization(guts.NFDNorm)
+
+	// NFKD is to NFKC what NFD is to NFC.
+	NFKD Normalization = Normalization(guts.NFKDNorm)
+
+	// NoNormalization compares runes exactly as given.  It is also the
+	// cheapest choice, as no copy of the input is made.
+	NoNormalization Normalization = Normalization(guts.NoNorm)
+)
+
+var normalizationNames = []string{
+	"NFKC",
+	"NFC",
+	"NFD",
+	"NFKD",
+	"NoNormalization",
+}
+
+func (x Normalization) String() string {
+	if uint(x) >= uint(len(normalizationNames)) {
+		return fmt.Sprintf("%%!Normalization(%d)", x)
+	}
+	return normalizationNames[x]
+}
+
+func (x Normalization) GoString() string {
+	if uint(x) >= uint(len(normalizationNames)) {
+		return fmt.Sprintf("Normalization(%d)", x)
+	}
+	return normalizationNames[x]
+}
+
+// WithNormalization selects the Unicode normalization form.  Because NFC and
+// NFD are canonically equivalent, NFC patterns match NFD input and vice
+// versa regardless of which of the two is chosen; choose NoNormalization for
+// byte-exact matching.
+func WithNormalization(form Normalization) Option {
+	return func(opts *options) {
+		opts.impl.Form = guts.NormForm(form)
+	}
+}
+
+var _ fmt.Stringer = Normalization(0)
+var _ fmt.GoStringer = Normalization(0)
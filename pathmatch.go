@@ -0,0 +1,397 @@
+package glob
+
+import (
+	"container/list"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// ErrBadPattern is returned by Match for a malformed pattern.  It is the same
+// value as path.ErrBadPattern, so existing comparisons keep working.
+var ErrBadPattern = path.ErrBadPattern
+
+// Match reports whether name matches the shell pattern, exactly as path.Match
+// does, so that calls to path.Match can be replaced one at a time.  The
+// pattern syntax is:
+//
+//	'*'         matches any sequence of non-'/' characters
+//	'?'         matches any single non-'/' character
+//	'[' [ '^' ] { lo [ '-' hi ] } ']'
+//	            matches one character in (or, with '^', not in) the set
+//	'\\' c      matches c
+//	c           matches c, for any other c
+//
+// There is no "**" and no Unicode normalization.  Match returns
+// ErrBadPattern if any part of the pattern is malformed, whether or not name
+// matches.
+//
+// Like path.Match, Match does not backtrack into earlier stars: each run of
+// the pattern between stars matches as early as it can.  So "*[^a]*b" does
+// not match "x/b", although the same pattern compiled with the PathMatch
+// dialect does.
+//
+// The translations of the most recently used patterns are cached, so calling
+// Match in a loop with the same pattern is about as cheap as path.Match.
+func Match(pattern, name string) (bool, error) {
+	if !utf8.ValidString(pattern) {
+		// path.Match compares literals byte by byte, so a partial rune
+		// in the pattern may match part of a rune in name, which cannot
+		// be expressed in runes.
+		return path.Match(pattern, name)
+	}
+	return matchPath(pattern, name, true, true, ErrBadPattern)
+}
+
+// MatchFilepath is to filepath.Match what Match is to path.Match.  On
+// Windows, the separator is '\\' rather than '/', and there is no escape
+// character.  The error for a malformed pattern is filepath.ErrBadPattern,
+// which, unlike Match, it reports only if the match gets as far as the
+// malformed part.
+func MatchFilepath(pattern, name string) (bool, error) {
+	if !utf8.ValidString(pattern) {
+		return filepath.Match(pattern, name)
+	}
+	if filepath.Separator == '/' {
+		return matchPath(pattern, name, true, false, filepath.ErrBadPattern)
+	}
+
+	// Exchanging the separators gives the native separator the meaning of
+	// '/', and makes the original '/' an ordinary rune.
+	sep := string(filepath.Separator)
+	swap := strings.NewReplacer(sep, "/", "/", sep)
+	return matchPath(swap.Replace(pattern), swap.Replace(name), false, false, filepath.ErrBadPattern)
+}
+
+// pathChunk is a run of a path.Match pattern between stars.  A chunk with
+// neither '?' nor a set is matched as the unescaped literal; any other is
+// compiled into a native pattern in which each of length elements matches
+// exactly one rune.
+type pathChunk struct {
+	star    bool
+	bad     bool
+	never   bool
+	literal string
+	length  int
+	glob    *Glob
+}
+
+// pathCacheSize is the number of patterns whose chunks are kept by
+// pathChunks.
+const pathCacheSize = 64
+
+type pathCacheKey struct {
+	pattern string
+	escape  bool
+}
+
+type pathCacheEntry struct {
+	key    pathCacheKey
+	chunks []pathChunk
+}
+
+// pathCache holds the chunks of the most recently used patterns, so that
+// matching in a loop translates each pattern only once.
+var pathCache struct {
+	mu      sync.Mutex
+	order   list.List
+	entries map[pathCacheKey]*list.Element
+}
+
+// pathChunks splits pattern into chunks, translating each one.  The result
+// is shared, and must not be modified.
+func pathChunks(pattern string, escape bool) []pathChunk {
+	key := pathCacheKey{pattern, escape}
+	pathCache.mu.Lock()
+	if e, found := pathCache.entries[key]; found {
+		pathCache.order.MoveToFront(e)
+		pathCache.mu.Unlock()
+		return e.Value.(*pathCacheEntry).chunks
+	}
+	pathCache.mu.Unlock()
+
+	var chunks []pathChunk
+	for len(pattern) > 0 {
+		var c pathChunk
+		var text string
+		c.star, text, pattern = scanPathChunk(pattern, escape)
+		c.bad = !c.compile(text, escape)
+		chunks = append(chunks, c)
+	}
+
+	pathCache.mu.Lock()
+	defer pathCache.mu.Unlock()
+	if _, found := pathCache.entries[key]; found {
+		return chunks
+	}
+	if pathCache.entries == nil {
+		pathCache.entries = make(map[pathCacheKey]*list.Element, pathCacheSize)
+	}
+	pathCache.entries[key] = pathCache.order.PushFront(&pathCacheEntry{key, chunks})
+	if pathCache.order.Len() > pathCacheSize {
+		oldest := pathCache.order.Back()
+		pathCache.order.Remove(oldest)
+		delete(pathCache.entries, oldest.Value.(*pathCacheEntry).key)
+	}
+	return chunks
+}
+
+// matchPath follows the algorithm of path.Match, but matches each chunk
+// which is not a plain literal using a compiled Glob.  If checkRest is
+// false, it reports a malformed chunk only once the match reaches it, as
+// filepath.Match does.
+func matchPath(pattern, name string, escape bool, checkRest bool, errBad error) (bool, error) {
+	chunks := pathChunks(pattern, escape)
+
+Chunks:
+	for i := range chunks {
+		c := &chunks[i]
+		last := i == len(chunks)-1
+		if c.bad {
+			return false, errBad
+		}
+		if c.star && c.length == 0 {
+			// A trailing star matches the rest of name, unless it has a
+			// '/'.
+			return strings.IndexByte(name, '/') < 0, nil
+		}
+		if rest, ok := c.match(name); ok && (rest == "" || !last) {
+			name = rest
+			continue
+		}
+		if c.star {
+			// A star skips bytes, not runes, but cannot skip '/'.
+			for j := 0; j < len(name) && name[j] != '/'; j++ {
+				if rest, ok := c.match(name[j+1:]); ok && (rest == "" || !last) {
+					name = rest
+					continue Chunks
+				}
+			}
+		}
+
+		if checkRest {
+			for _, c := range chunks[i+1:] {
+				if c.bad {
+					return false, errBad
+				}
+			}
+		}
+		return false, nil
+	}
+	return name == "", nil
+}
+
+// scanPathChunk splits off the leading stars and the following chunk.  A
+// '*' between '[' and ']' does not end the chunk.
+func scanPathChunk(pattern string, escape bool) (bool, string, string) {
+	star := false
+	for len(pattern) > 0 && pattern[0] == '*' {
+		pattern = pattern[1:]
+		star = true
+	}
+	inSet := false
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			if escape && i+1 < len(pattern) {
+				i++
+			}
+		case '[':
+			inSet = true
+		case ']':
+			inSet = false
+		case '*':
+			if !inSet {
+				return star, pattern[:i], pattern[i:]
+			}
+		}
+	}
+	return star, pattern, ""
+}
+
+// compile translates the chunk, returning false if it is malformed.  A chunk
+// which can never match, because of a set whose ranges are all backwards,
+// is marked never.
+func (c *pathChunk) compile(chunk string, escape bool) bool {
+	var native []rune
+	var literal []byte
+	plain := true
+	for i := 0; i < len(chunk); {
+		c.length++
+		switch ch := chunk[i]; {
+		case ch == '[':
+			plain = false
+			i++
+			negated := i < len(chunk) && chunk[i] == '^'
+			if negated {
+				i++
+			}
+			var ranges []guts.LoHi
+			for n := 0; ; n++ {
+				if i < len(chunk) && chunk[i] == ']' && n > 0 {
+					i++
+					break
+				}
+				lo, next, ok := pathSetRune(chunk, i, escape)
+				if !ok {
+					return false
+				}
+				i = next
+				hi := lo
+				if chunk[i] == '-' {
+					if hi, next, ok = pathSetRune(chunk, i+1, escape); !ok {
+						return false
+					}
+					i = next
+				}
+				// path.Match accepts a backwards range, which matches
+				// nothing.
+				if lo <= hi {
+					ranges = append(ranges, guts.LoHi{Lo: lo, Hi: hi})
+				}
+			}
+
+			ranges = rawByteRanges(ranges)
+			switch {
+			case len(ranges) != 0:
+				native = append(native, '[')
+				if negated {
+					native = append(native, '^')
+				}
+				for _, r := range ranges {
+					native = guts.SafeAppendRune(native, r.Lo)
+					if r.Hi != r.Lo {
+						native = append(native, '-')
+						native = guts.SafeAppendRune(native, r.Hi)
+					}
+				}
+				native = append(native, ']')
+			case negated:
+				native = append(native, []rune(`[\0-\U0010ffff]`)...)
+			default:
+				c.never = true
+			}
+
+		case ch == '?':
+			plain = false
+			native = append(native, '?')
+			i++
+
+		default:
+			if ch == '\\' && escape {
+				i++
+				if i == len(chunk) {
+					return false
+				}
+			}
+			r, size := utf8.DecodeRuneInString(chunk[i:])
+			native = guts.SafeAppendRune(native, r)
+			literal = append(literal, chunk[i:i+size]...)
+			i += size
+		}
+	}
+
+	if c.never {
+		return true
+	}
+	if plain {
+		c.literal = string(literal)
+		return true
+	}
+	c.glob = new(Glob)
+	if err := c.glob.impl.CompileWith(string(native), guts.Options{Form: guts.NoNorm}); err != nil {
+		panic(fmt.Errorf("BUG! translation %q of path.Match pattern %q: %v", string(native), chunk, err))
+	}
+	return true
+}
+
+// pathSetRune returns the possibly escaped member of a set at chunk[i], and
+// the offset just past it.  Like path.Match, it rejects an unescaped '-' or
+// ']', and a set which the chunk ends before closing.
+func pathSetRune(chunk string, i int, escape bool) (rune, int, bool) {
+	if i >= len(chunk) || chunk[i] == '-' || chunk[i] == ']' {
+		return 0, 0, false
+	}
+	if escape && chunk[i] == '\\' {
+		i++
+		if i >= len(chunk) {
+			return 0, 0, false
+		}
+	}
+	r, size := utf8.DecodeRuneInString(chunk[i:])
+	i += size
+	if i >= len(chunk) {
+		return 0, 0, false
+	}
+	return r, i, true
+}
+
+// rawByteRanges adjusts the ranges of a set for input decoded with
+// guts.DecodeRawRune.  path.Match decodes each byte of invalid UTF-8 as
+// utf8.RuneError, so a raw byte rune must be a member exactly when
+// utf8.RuneError is.
+func rawByteRanges(ranges []guts.LoHi) []guts.LoHi {
+	out := make([]guts.LoHi, 0, len(ranges)+1)
+	hasRuneError := false
+	for _, r := range ranges {
+		if r.Lo <= utf8.RuneError && utf8.RuneError <= r.Hi {
+			hasRuneError = true
+		}
+		if r.Lo < guts.RawByteLo {
+			hi := r.Hi
+			if hi >= guts.RawByteLo {
+				hi = guts.RawByteLo - 1
+			}
+			out = append(out, guts.LoHi{Lo: r.Lo, Hi: hi})
+		}
+		if r.Hi > guts.RawByteHi {
+			lo := r.Lo
+			if lo <= guts.RawByteHi {
+				lo = guts.RawByteHi + 1
+			}
+			out = append(out, guts.LoHi{Lo: lo, Hi: r.Hi})
+		}
+	}
+	if hasRuneError {
+		out = append(out, guts.LoHi{Lo: guts.RawByteLo, Hi: guts.RawByteHi})
+	}
+	return out
+}
+
+// match matches the chunk against the start of s, returning the rest of s.
+// Like path.Match, it takes one rune of s for each element of the chunk,
+// and each byte of invalid UTF-8 counts as a rune.
+func (c *pathChunk) match(s string) (string, bool) {
+	if c.never {
+		return "", false
+	}
+	if c.glob == nil {
+		// path.Match compares literals byte by byte.
+		if !strings.HasPrefix(s, c.literal) {
+			return "", false
+		}
+		return s[len(c.literal):], true
+	}
+
+	n := 0
+	for k := 0; k < c.length; k++ {
+		if n == len(s) {
+			return "", false
+		}
+		_, size := utf8.DecodeRuneInString(s[n:])
+		n += size
+	}
+
+	var ok bool
+	if utf8.ValidString(s[:n]) {
+		ok = c.glob.Match(s[:n])
+	} else {
+		ok = c.glob.MatchBytes([]byte(s[:n]))
+	}
+	return s[n:], ok
+}
@@ -0,0 +1,131 @@
+package glob
+
+import (
+	"math/rand"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	type testrow struct {
+		Pattern string
+		Name    string
+		Expect  bool
+		Err     error
+	}
+
+	testdata := [...]testrow{
+		{"abc", "abc", true, nil},
+		{"*", "abc", true, nil},
+		{"*", "a/b", false, nil},
+		{"a*/b", "abc/b", true, nil},
+		{"a*b*c*d*e*/f", "axbxcxdxexxx/f", true, nil},
+		{"a?c", "a/c", false, nil},
+		{"a[^a]c", "a/c", true, nil},
+		{"[a-c]", "b", true, nil},
+		{"[c-a]", "b", false, nil},
+		{"[^c-a]", "b", true, nil},
+		{"[!a]", "!", true, nil},
+		{`a\*b`, "a*b", true, nil},
+		{`[\-]`, "-", true, nil},
+		{"**", "a/b", false, nil},
+		{"*[^a]*b", "x/b", false, nil},
+		{"*??", "€", true, nil},
+		{"[^a]", "\xff", true, nil},
+		{"[�]", "\xff", true, nil},
+		{"�", "\xff", false, nil},
+		{"[퀀-]", "\xff", false, nil},
+		{"*x", "\xffx", true, nil},
+		{"", "", true, nil},
+		{"[]a]", "]", false, ErrBadPattern},
+		{"[a-]", "a", false, ErrBadPattern},
+		{"[-a]", "a", false, ErrBadPattern},
+		{"[a-b-c]", "a", false, ErrBadPattern},
+		{"[^]", "a", false, ErrBadPattern},
+		{"[a", "a", false, ErrBadPattern},
+		{`a\`, "a", false, ErrBadPattern},
+		{"x*[", "y", false, ErrBadPattern},
+		{"[\xff]", "a", false, ErrBadPattern},
+	}
+
+	for _, row := range testdata {
+		matched, err := Match(row.Pattern, row.Name)
+		if matched != row.Expect || err != row.Err {
+			t.Errorf("Match(%q, %q): expected (%v, %v), got (%v, %v)", row.Pattern, row.Name, row.Expect, row.Err, matched, err)
+		}
+		stdMatched, stdErr := path.Match(row.Pattern, row.Name)
+		if stdMatched != row.Expect || stdErr != row.Err {
+			t.Errorf("path.Match(%q, %q): expected (%v, %v), got (%v, %v)", row.Pattern, row.Name, row.Expect, row.Err, stdMatched, stdErr)
+		}
+	}
+
+	// Unlike Match, a compiled pattern backtracks.
+	if g := MustCompile("*[^a]*b", WithDialect(PathMatch)); !g.Match("x/b") {
+		t.Errorf("PathMatch dialect: expected %q to match %v", "x/b", g)
+	}
+}
+
+func TestMatch_Differential(t *testing.T) {
+	patternAlphabet := []string{"a", "b", "/", "*", "*", "?", "[", "]", "^", "-", `\`, "é", "€", "�", "\xe2"}
+	nameAlphabet := []string{"a", "b", "/", "-", "]", "^", "*", `\`, "é", "€", "\xe2", "\xff"}
+	rng := rand.New(rand.NewSource(1))
+	random := func(alphabet []string, max int) string {
+		var s string
+		for n := rng.Intn(max + 1); n > 0; n-- {
+			s += alphabet[rng.Intn(len(alphabet))]
+		}
+		return s
+	}
+
+	for i := 0; i < 100000; i++ {
+		pattern := random(patternAlphabet, 8)
+		name := random(nameAlphabet, 8)
+
+		expectMatched, expectErr := path.Match(pattern, name)
+		matched, err := Match(pattern, name)
+		if matched != expectMatched || err != expectErr {
+			t.Errorf("Match(%q, %q): expected (%v, %v), got (%v, %v)", pattern, name, expectMatched, expectErr, matched, err)
+		}
+
+		expectMatched, expectErr = filepath.Match(pattern, name)
+		matched, err = MatchFilepath(pattern, name)
+		if matched != expectMatched || err != expectErr {
+			t.Errorf("MatchFilepath(%q, %q): expected (%v, %v), got (%v, %v)", pattern, name, expectMatched, expectErr, matched, err)
+		}
+	}
+
+	if n := len(pathCache.entries); n > pathCacheSize {
+		t.Errorf("%d patterns cached, limit is %d", n, pathCacheSize)
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	type testrow struct {
+		Pattern string
+		Name    string
+	}
+
+	testdata := [...]testrow{
+		{"*.go", "match.go"},
+		{"src/*/*_test.go", "src/guts/match_test.go"},
+		{"[a-z]*/?atch.go", "guts/match.go"},
+	}
+
+	for _, row := range testdata {
+		b.Run(row.Pattern, func(b *testing.B) {
+			b.Run("Match", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					Match(row.Pattern, row.Name)
+				}
+			})
+			b.Run("path.Match", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					path.Match(row.Pattern, row.Name)
+				}
+			})
+		})
+	}
+}
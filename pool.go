@@ -0,0 +1,43 @@
+package glob
+
+import (
+	"sync"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+var matcherPool = sync.Pool{New: newMatcher}
+
+func newMatcher() interface{} {
+	return new(Matcher)
+}
+
+// AcquireMatcher is like Matcher, but reuses a Matcher from a pool when one
+// is available.  Call Release once the Matcher and its Capture are no longer
+// needed.
+func (g *Glob) AcquireMatcher(input string) *Matcher {
+	if g.op != leafOp {
+		return g.Matcher(input)
+	}
+	m := matcherPool.Get().(*Matcher)
+	m.init(g)
+	m.impl.Form = g.impl.Options.Form
+	m.impl.Budget = m.budgetPtr()
+	m.impl.Reset(m.g, input)
+	return m
+}
+
+// Release returns a Matcher obtained from AcquireMatcher to the pool.  The
+// Matcher must not be used afterward.
+func (m *Matcher) Release() {
+	if m.x != nil {
+		// Matchers for combined Globs are not pooled.
+		return
+	}
+	m.g = nil
+	m.c = Capture{}
+	m.budget = guts.Budget{}
+	m.impl.Budget = nil
+	m.impl.Trace = nil
+	matcherPool.Put(m)
+}
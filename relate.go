@@ -0,0 +1,55 @@
+package glob
+
+import (
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// Subsumes returns true iff every string matched by b is also matched by a.
+//
+// The answer is computed exactly, by comparing automata built from the two
+// patterns, rather than by testing examples.  Both patterns should use the
+// same Normalization; the comparison is over the strings that reach the
+// matcher after normalization.
+func Subsumes(a, b *Glob) bool {
+	_, found := Counterexample(a, b)
+	return !found
+}
+
+// Counterexample returns the shortest string which b matches but a does not,
+// or false if a subsumes b.
+func Counterexample(a, b *Glob) (string, bool) {
+	return search([]*Glob{a, b}, func(in []bool) bool {
+		return in[1] && !in[0]
+	})
+}
+
+// Overlaps returns true iff some string is matched by both a and b, along
+// with the shortest such string.
+func Overlaps(a, b *Glob) (bool, string) {
+	example, found := search([]*Glob{a, b}, func(in []bool) bool {
+		return in[0] && in[1]
+	})
+	return found, example
+}
+
+// search looks for the shortest string for which pred returns true, given
+// whether each of globs matches it.  Globs built by Or, And and Not are
+// searched through the automata of their leaves.
+func search(globs []*Glob, pred func(in []bool) bool) (string, bool) {
+	var leaves []*Glob
+	for _, g := range globs {
+		leaves = g.leaves(leaves)
+	}
+	nfas := make([]*guts.NFA, len(leaves))
+	for i, leaf := range leaves {
+		nfas[i] = guts.BuildNFA(&leaf.impl)
+	}
+	in := make([]bool, len(globs))
+	return guts.Search(nfas, func(accepts []bool) bool {
+		i := 0
+		for j, g := range globs {
+			in[j] = g.eval(accepts, &i)
+		}
+		return pred(in)
+	})
+}
@@ -0,0 +1,80 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestSubsumes(t *testing.T) {
+	type testRow struct {
+		A        string
+		B        string
+		Expected bool
+		Example  string
+	}
+
+	testData := [...]testRow{
+		{"**", "src/*.go", true, ""},
+		{"src/**", "src/*.go", true, ""},
+		{"src/*.go", "src/**", false, "src/"},
+		{"src/**/*.go", "src/*.go", true, ""},
+		{"src/*.go", "src/**/*.go", false, "src//.go"},
+		{"*", "a/b", false, "a/b"},
+		{"[a-z]", "[b-c]", true, ""},
+		{"[b-c]", "[a-z]", false, "a"},
+		{"?", "[^/]", true, ""},
+		{"a*", "a**", false, "a/"},
+		{"**/", "**/**/", true, ""},
+		{"**/**/", "**/", true, ""},
+		{"*.go", "*.go", true, ""},
+		{"*_test.go", "*.go", false, ".go"},
+	}
+
+	for _, row := range testData {
+		t.Run(row.A+"/"+row.B, func(t *testing.T) {
+			a := MustCompile(row.A)
+			b := MustCompile(row.B)
+			if actual := Subsumes(a, b); actual != row.Expected {
+				t.Errorf("Subsumes: expected %v, got %v", row.Expected, actual)
+			}
+			example, found := Counterexample(a, b)
+			if found == row.Expected || example != row.Example {
+				t.Errorf("Counterexample: expected %q, %v, got %q, %v", row.Example, !row.Expected, example, found)
+			}
+			if found && (!b.Match(example) || a.Match(example)) {
+				t.Errorf("Counterexample: %q is not a counterexample", example)
+			}
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	type testRow struct {
+		A        string
+		B        string
+		Expected bool
+		Example  string
+	}
+
+	testData := [...]testRow{
+		{"*.go", "*_test.go", true, "_test.go"},
+		{"*.go", "*.c", false, ""},
+		{"src/**", "**/*.go", true, "src/.go"},
+		{"a/*", "*/b", true, "a/b"},
+		{"[a-c]", "[d-f]", false, ""},
+		{"*", "a/**", false, ""},
+	}
+
+	for _, row := range testData {
+		t.Run(row.A+"/"+row.B, func(t *testing.T) {
+			a := MustCompile(row.A)
+			b := MustCompile(row.B)
+			actual, example := Overlaps(a, b)
+			if actual != row.Expected || example != row.Example {
+				t.Errorf("expected %v, %q, got %v, %q", row.Expected, row.Example, actual, example)
+			}
+			if actual && (!a.Match(example) || !b.Match(example)) {
+				t.Errorf("%q is not matched by both", example)
+			}
+		})
+	}
+}
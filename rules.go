@@ -0,0 +1,247 @@
+package glob
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// Verdict is the outcome of evaluating Rules against an input.
+type Verdict byte
+
+const (
+	// Unmatched indicates that no rule matched the input.
+	Unmatched Verdict = iota
+
+	// Included indicates that the deciding rule was an include rule.
+	Included
+
+	// Excluded indicates that the deciding rule was an exclude rule.
+	Excluded
+)
+
+var verdictNames = []string{
+	"Unmatched",
+	"Included",
+	"Excluded",
+}
+
+func (x Verdict) String() string {
+	if uint(x) >= uint(len(verdictNames)) {
+		return fmt.Sprintf("%%!Verdict(%d)", x)
+	}
+	return verdictNames[x]
+}
+
+func (x Verdict) GoString() string {
+	if uint(x) >= uint(len(verdictNames)) {
+		return fmt.Sprintf("Verdict(%d)", x)
+	}
+	return verdictNames[x]
+}
+
+// Rule is one entry of a Rules list.  Line is the 1-based line number the
+// rule was parsed from, or 0 if it came from NewRules.
+type Rule struct {
+	Include bool
+	Glob    *Glob
+	Line    uint
+}
+
+func (r Rule) String() string {
+	if r.Include {
+		return "+ " + r.Glob.String()
+	}
+	return "- " + r.Glob.String()
+}
+
+// RuleError is returned by ParseRules for a malformed line.
+type RuleError struct {
+	Line uint
+	Text string
+	Err  error
+}
+
+func (err *RuleError) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", err.Line, err.Text, err.Err)
+}
+
+// Rules is an ordered list of include and exclude rules, in which the last
+// rule to match an input decides.  All rules are evaluated together, in a
+// single pass over the input, by an automaton which is built lazily and
+// cached as inputs are matched.
+//
+// A Rules is safe for concurrent use.
+type Rules struct {
+	rules []Rule
+	owner []int
+	first []int
+	exact bool
+	form  guts.NormForm
+	dfa   *guts.LazyDFA
+}
+
+// ParseRules parses rules in the style of rsync filters, one per line:
+//
+//	# Comments and blank lines are ignored.
+//	+ src/**/*.go
+//	- **/*_test.go
+//
+// Each line is '+' (include) or '-' (exclude), a single space, and a
+// pattern, which runs to the end of the line.  A malformed line produces a
+// *RuleError, whose Err is a *SyntaxError if the pattern failed to compile.
+func ParseRules(text string, opts ...Option) (*Rules, error) {
+	var rules []Rule
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	var lineno uint
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var include bool
+		switch {
+		case strings.HasPrefix(line, "+ "):
+			include = true
+		case strings.HasPrefix(line, "- "):
+			include = false
+		default:
+			return nil, &RuleError{Line: lineno, Text: line, Err: fmt.Errorf("expected \"+ \" or \"- \"")}
+		}
+
+		g, err := Compile(line[2:], opts...)
+		if err != nil {
+			return nil, &RuleError{Line: lineno, Text: line, Err: err}
+		}
+		rules = append(rules, Rule{Include: include, Glob: g, Line: lineno})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return newRules(rules, buildOptions(opts)), nil
+}
+
+// NewRules builds rules in the style of Bazel's glob(include, exclude): an
+// input is Included if it matches any include pattern and no exclude
+// pattern, and Excluded if it matches any exclude pattern.  This is the same
+// as listing every include rule followed by every exclude rule.
+func NewRules(include, exclude []string, opts ...Option) (*Rules, error) {
+	rules := make([]Rule, 0, len(include)+len(exclude))
+	add := func(pattern string, isInclude bool) error {
+		g, err := Compile(pattern, opts...)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, Rule{Include: isInclude, Glob: g})
+		return nil
+	}
+	for _, pattern := range include {
+		if err := add(pattern, true); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range exclude {
+		if err := add(pattern, false); err != nil {
+			return nil, err
+		}
+	}
+	return newRules(rules, buildOptions(opts)), nil
+}
+
+func newRules(rules []Rule, o options) *Rules {
+	// A pattern with braces compiles to an Or of leaves, each of which
+	// gets its own accepting state; owner maps them back to the rule, and
+	// the leaves of rule i are first[i] up to first[i+1].
+	var leaves []*guts.Glob
+	owner := make([]int, 0, len(rules))
+	first := make([]int, 0, len(rules)+1)
+	exact := true
+	for i := range rules {
+		first = append(first, len(leaves))
+		for _, leaf := range rules[i].Glob.leaves(nil) {
+			leaves = append(leaves, &leaf.impl)
+			owner = append(owner, i)
+		}
+		exact = exact && rules[i].Glob.onlyOr()
+	}
+	first = append(first, len(leaves))
+	return &Rules{
+		rules: rules,
+		owner: owner,
+		first: first,
+		exact: exact,
+		form:  o.impl.Form,
+		dfa:   guts.NewLazyDFA(guts.BuildMultiNFA(leaves)),
+	}
+}
+
+// Rules returns the rules, in order.
+func (r *Rules) Rules() []Rule {
+	return r.rules
+}
+
+// Match evaluates the rules against input, and returns the verdict and the
+// rule which decided it, or Unmatched and nil if no rule matched.
+func (r *Rules) Match(input string) (Verdict, *Rule) {
+	if r.form != guts.NoNorm {
+		if form := r.form.Form(); !form.IsNormalString(input) {
+			input = form.String(input)
+		}
+	}
+	state := r.dfa.Run(input)
+	if r.exact {
+		// Any accepting leaf means that its rule matches.
+		if state.Last < 0 {
+			return Unmatched, nil
+		}
+		return r.verdict(r.owner[state.Last])
+	}
+
+	// With And or Not, a leaf may accept without its rule matching, or
+	// the reverse, so each rule must be evaluated in turn.
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		p, q := r.first[i], r.first[i+1]
+		accepts := make([]bool, q-p)
+		for k := range accepts {
+			accepts[k] = state.Set.Has(r.dfa.NFA.Accepts[p+k])
+		}
+		k := 0
+		if r.rules[i].Glob.eval(accepts, &k) {
+			return r.verdict(i)
+		}
+	}
+	return Unmatched, nil
+}
+
+func (r *Rules) verdict(i int) (Verdict, *Rule) {
+	rule := &r.rules[i]
+	if rule.Include {
+		return Included, rule
+	}
+	return Excluded, rule
+}
+
+// Includes returns true iff the verdict for input is Included.
+func (r *Rules) Includes(input string) bool {
+	verdict, _ := r.Match(input)
+	return verdict == Included
+}
+
+func (r *Rules) String() string {
+	var buf strings.Builder
+	for _, rule := range r.rules {
+		buf.WriteString(rule.String())
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+var _ fmt.Stringer = Verdict(0)
+var _ fmt.GoStringer = Verdict(0)
+var _ fmt.Stringer = Rule{}
+var _ fmt.Stringer = (*Rules)(nil)
+var _ error = (*RuleError)(nil)
@@ -0,0 +1,162 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(`
+# Go sources, but not tests.
++ **/*.go
+- **/*_test.go
++ **/testdata/**
+- vendor/**
+`)
+	if err != nil {
+		t.Fatalf("ParseRules: unexpected error: %v", err)
+	}
+
+	type testRow struct {
+		Input   string
+		Verdict Verdict
+		Line    uint
+	}
+
+	testData := [...]testRow{
+		{"main.go", Included, 3},
+		{"src/lib.go", Included, 3},
+		{"src/lib_test.go", Excluded, 4},
+		{"src/testdata/x_test.go", Included, 5},
+		{"vendor/x/y.go", Excluded, 6},
+		{"README.md", Unmatched, 0},
+	}
+
+	for _, row := range testData {
+		verdict, rule := rules.Match(row.Input)
+		if verdict != row.Verdict {
+			t.Errorf("%q: expected %v, got %v", row.Input, row.Verdict, verdict)
+		}
+		var line uint
+		if rule != nil {
+			line = rule.Line
+		}
+		if line != row.Line {
+			t.Errorf("%q: expected line %d, got %d", row.Input, row.Line, line)
+		}
+		if rules.Includes(row.Input) != (row.Verdict == Included) {
+			t.Errorf("%q: Includes disagrees with Match", row.Input)
+		}
+
+		// Evaluating the rules one at a time must agree.
+		expect := Unmatched
+		for _, rule := range rules.Rules() {
+			if rule.Glob.Match(row.Input) {
+				expect = Excluded
+				if rule.Include {
+					expect = Included
+				}
+			}
+		}
+		if verdict != expect {
+			t.Errorf("%q: expected %v from one-by-one evaluation, got %v", row.Input, expect, verdict)
+		}
+	}
+
+	for _, text := range []string{"* foo", "+foo", "+ [a"} {
+		if _, err := ParseRules(text); err == nil {
+			t.Errorf("ParseRules(%q): expected error", text)
+		} else if rerr, ok := err.(*RuleError); !ok || rerr.Line != 1 {
+			t.Errorf("ParseRules(%q): expected *RuleError on line 1, got %#v", text, err)
+		}
+	}
+}
+
+func TestNewRules(t *testing.T) {
+	rules, err := NewRules([]string{"**/*.go", "**/*.s"}, []string{"**/*_test.go"})
+	if err != nil {
+		t.Fatalf("NewRules: unexpected error: %v", err)
+	}
+	if s := rules.String(); s != "+ **/*.go\n+ **/*.s\n- **/*_test.go\n" {
+		t.Errorf("String: unexpected %q", s)
+	}
+
+	expect := map[string]Verdict{
+		"a.go":      Included,
+		"x/a.s":     Included,
+		"a_test.go": Excluded,
+		"a.c":       Unmatched,
+	}
+	for input, verdict := range expect {
+		if actual, _ := rules.Match(input); actual != verdict {
+			t.Errorf("%q: expected %v, got %v", input, verdict, actual)
+		}
+	}
+
+	if _, err := NewRules(nil, []string{"[a"}); err == nil {
+		t.Errorf("NewRules: expected error")
+	}
+}
+
+func TestRules_Negated(t *testing.T) {
+	rules, err := ParseRules("+ !(a)\n+ *.js\n- !(a).js\n", WithDialect(Minimatch))
+	if err != nil {
+		t.Fatalf("ParseRules: unexpected error: %v", err)
+	}
+
+	type testRow struct {
+		Input   string
+		Verdict Verdict
+		Line    uint
+	}
+
+	testData := [...]testRow{
+		{"a", Unmatched, 0},
+		{"b", Included, 1},
+		{"a.js", Included, 2},
+		{"c.js", Excluded, 3},
+		{"x/c", Unmatched, 0},
+	}
+
+	for _, row := range testData {
+		verdict, rule := rules.Match(row.Input)
+		var line uint
+		if rule != nil {
+			line = rule.Line
+		}
+		if verdict != row.Verdict || line != row.Line {
+			t.Errorf("%q: expected %v from line %d, got %v from line %d", row.Input, row.Verdict, row.Line, verdict, line)
+		}
+	}
+}
+
+func BenchmarkRules_Match(b *testing.B) {
+	rules, _ := NewRules(
+		[]string{"src/**/*.go", "src/**/*.s", "cmd/*/main.go", "**/testdata/**"},
+		[]string{"**/*_test.go", "vendor/**", "**/.*/**"})
+	for i := 0; i < b.N; i++ {
+		rules.Match("src/github.com/team-spectre/go-glob/internal/guts/match.go")
+	}
+}
+
+func TestRules_Concurrent(t *testing.T) {
+	rules, _ := NewRules([]string{"**/*.go"}, []string{"**/*_test.go"})
+	inputs := []string{"a.go", "a_test.go", "b/c.go", "d.c", "é/ü.go"}
+
+	done := make(chan struct{})
+	for n := 0; n < 4; n++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for i := 0; i < 100; i++ {
+				for _, input := range inputs {
+					if actual, expect := rules.Includes(input), MustCompile("**/*.go").Match(input) && !MustCompile("**/*_test.go").Match(input); actual != expect {
+						t.Errorf("%q: expected %v, got %v", input, expect, actual)
+						return
+					}
+				}
+			}
+		}()
+	}
+	for n := 0; n < 4; n++ {
+		<-done
+	}
+}
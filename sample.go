@@ -0,0 +1,226 @@
+package glob
+
+import (
+	"math/rand"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// SampleOptions controls the strings generated by Sample.  A nil
+// *SampleOptions selects the defaults.
+type SampleOptions struct {
+	// MaxDepth limits the number of path components generated for each
+	// "**" or "**/".  The default is 3.
+	MaxDepth int
+
+	// MaxLength limits the number of runes generated for each "*", and
+	// for each path component of a "**".  The default is 8.
+	MaxLength int
+
+	// Alphabet is the set of runes used for "*", "**" and "?", and
+	// preferred for character sets.  '/' is ignored.  The default is
+	// lowercase ASCII letters, digits, '-' and '_'.
+	Alphabet string
+
+	// NearMiss asks for a string which does not match, but which is a
+	// small edit away from one which does.
+	NearMiss bool
+}
+
+const defaultSampleAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// sampleAttempts is how many random candidates Sample tries before falling
+// back to a deterministic answer.
+const sampleAttempts = 100
+
+type sampler struct {
+	rng       *rand.Rand
+	maxDepth  int
+	maxLength int
+	alphabet  []rune
+	preferred guts.RuneMatcher
+}
+
+// Sample returns a random string which matches g, for use in property
+// tests and as an example in documentation.  Runes for character sets are
+// drawn from the set's ranges.  With NearMiss, the string instead fails to
+// match.
+//
+// If no random candidate works out, which can happen when normalization
+// changes the generated runes, Sample returns the shortest suitable string
+// instead.  If there is no suitable string at all, e.g. a NearMiss for
+// "**", Sample returns "".
+func (g *Glob) Sample(rng *rand.Rand, opts *SampleOptions) string {
+	if opts == nil {
+		opts = &SampleOptions{}
+	}
+	s := &sampler{rng: rng, maxDepth: opts.MaxDepth, maxLength: opts.MaxLength}
+	if s.maxDepth <= 0 {
+		s.maxDepth = 3
+	}
+	if s.maxLength <= 0 {
+		s.maxLength = 8
+	}
+	alphabet := opts.Alphabet
+	if alphabet == "" {
+		alphabet = defaultSampleAlphabet
+	}
+	for _, ch := range alphabet {
+		if ch != '/' {
+			s.alphabet = append(s.alphabet, ch)
+		}
+	}
+	if len(s.alphabet) == 0 {
+		s.alphabet = []rune(defaultSampleAlphabet)
+	}
+	s.preferred = guts.None()
+	for _, ch := range s.alphabet {
+		s.preferred = guts.Union(s.preferred, guts.Is(ch))
+	}
+
+	want := !opts.NearMiss
+	for attempt := 0; attempt < sampleAttempts; attempt++ {
+		var str string
+		if opts.NearMiss {
+			str = s.nearMiss(g)
+		} else {
+			str = s.sample(g, true)
+		}
+		if g.Match(str) == want {
+			return str
+		}
+	}
+
+	str, _ := search([]*Glob{g}, func(in []bool) bool {
+		return in[0] == want
+	})
+	return str
+}
+
+// sample generates a string which probably matches g, or probably does not
+// if want is false.
+func (s *sampler) sample(g *Glob, want bool) string {
+	switch g.op {
+	case orOp:
+		return s.sample(g.operands[s.rng.Intn(len(g.operands))], want)
+	case andOp:
+		if !want {
+			return s.sample(g.operands[s.rng.Intn(len(g.operands))], false)
+		}
+		return s.sample(g.operands[0], true)
+	case notOp:
+		return s.sample(g.operands[0], !want)
+	}
+	if !want {
+		return s.nearMiss(g)
+	}
+	return guts.EncodeRawRunes(s.leaf(&g.impl))
+}
+
+func (s *sampler) leaf(g *guts.Glob) []rune {
+	var out []rune
+	for i := range g.Segments {
+		seg := &g.Segments[i]
+		switch seg.Type {
+		case guts.LiteralSegment:
+			out = append(out, seg.Literal.Runes...)
+		case guts.RuneMatchSegment:
+			if ch, ok := s.runeFrom(seg.Matcher); ok {
+				out = append(out, ch)
+			}
+		case guts.QuestionSegment:
+			out = append(out, s.word(1)...)
+		case guts.StarSegment:
+			out = append(out, s.word(s.rng.Intn(s.maxLength+1))...)
+		case guts.DoubleStarSegment:
+			depth := s.rng.Intn(s.maxDepth + 1)
+			for j := 0; j < depth; j++ {
+				if j > 0 {
+					out = append(out, '/')
+				}
+				out = append(out, s.word(1+s.rng.Intn(s.maxLength))...)
+			}
+		case guts.DoubleStarSlashSegment:
+			depth := s.rng.Intn(s.maxDepth + 1)
+			for j := 0; j < depth; j++ {
+				out = append(out, s.word(1+s.rng.Intn(s.maxLength))...)
+				out = append(out, '/')
+			}
+		}
+	}
+	return out
+}
+
+// word returns n random runes from the alphabet.
+func (s *sampler) word(n int) []rune {
+	out := make([]rune, n)
+	for i := range out {
+		out[i] = s.alphabet[s.rng.Intn(len(s.alphabet))]
+	}
+	return out
+}
+
+// runeFrom picks a random rune from m, preferring runes of the alphabet.
+func (s *sampler) runeFrom(m guts.RuneMatcher) (rune, bool) {
+	if s.rng.Intn(4) != 0 {
+		if ch, ok := s.uniform(guts.Ranges(guts.Intersect(m, s.preferred))); ok {
+			return ch, true
+		}
+	}
+	return s.uniform(possibleRunes(guts.Ranges(m)))
+}
+
+func (s *sampler) uniform(ranges guts.SortedLoHi) (rune, bool) {
+	total := countRunes(ranges)
+	if total == 0 {
+		return 0, false
+	}
+	k := uint64(s.rng.Int63n(int64(total)))
+	for _, r := range ranges {
+		size := uint64(r.Hi-r.Lo) + 1
+		if k < size {
+			return r.Lo + rune(k), true
+		}
+		k -= size
+	}
+	panic("BUG! rune index out of range")
+}
+
+// nearMiss makes one small random edit to a matching sample: it deletes,
+// inserts or replaces a single rune, where the inserted rune may be '/'.
+func (s *sampler) nearMiss(g *Glob) string {
+	var runes []rune
+	str := s.sample(g, true)
+	for i := 0; i < len(str); {
+		ch, size := guts.DecodeRawRune(str[i:])
+		runes = append(runes, ch)
+		i += size
+	}
+	pick := func() rune {
+		if s.rng.Intn(4) == 0 {
+			return '/'
+		}
+		return s.alphabet[s.rng.Intn(len(s.alphabet))]
+	}
+	i := 0
+	if len(runes) > 0 {
+		i = s.rng.Intn(len(runes))
+	}
+	switch s.rng.Intn(3) {
+	case 0:
+		if len(runes) > 0 {
+			runes = append(runes[:i], runes[i+1:]...)
+			break
+		}
+		fallthrough
+	case 1:
+		runes = append(runes[:i], append([]rune{pick()}, runes[i:]...)...)
+	default:
+		if len(runes) > 0 {
+			runes[i] = pick()
+		} else {
+			runes = append(runes, pick())
+		}
+	}
+	return guts.EncodeRawRunes(runes)
+}
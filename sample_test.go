@@ -0,0 +1,61 @@
+package glob
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestGlob_Sample(t *testing.T) {
+	globs := []*Glob{
+		MustCompile(""),
+		MustCompile("abc"),
+		MustCompile("src/**/*.go"),
+		MustCompile("**/[0-9][a-f]?/*_test.go"),
+		MustCompile("[^a-z]*"),
+		MustCompile("a**b"),
+		MustCompile("**/"),
+		MustCompile("ﬁ*", WithNormalization(NFKC)),
+		Or(MustCompile("*.c"), MustCompile("*.h")),
+		And(MustCompile("**/*.go"), Not(MustCompile("**/*_test.go"))),
+		Not(MustCompile("*")),
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for _, g := range globs {
+		t.Run(g.String(), func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				str := g.Sample(rng, nil)
+				if !g.Match(str) {
+					t.Fatalf("Sample: %q does not match", str)
+				}
+				str = g.Sample(rng, &SampleOptions{NearMiss: true})
+				if g.Match(str) {
+					t.Fatalf("Sample NearMiss: %q matches", str)
+				}
+			}
+		})
+	}
+
+	opts := &SampleOptions{MaxDepth: 2, MaxLength: 3, Alphabet: "xy"}
+	g := MustCompile("**/*")
+	for i := 0; i < 100; i++ {
+		str := g.Sample(rng, opts)
+		if strings.Count(str, "/") > 2 || strings.Trim(str, "xy/") != "" {
+			t.Fatalf("Sample with options: unexpected %q", str)
+		}
+	}
+
+	// A near miss edits one rune, so of two raw bytes, one survives.
+	g = MustCompile("\xff\xfe")
+	for i := 0; i < 100; i++ {
+		str := g.Sample(rng, &SampleOptions{NearMiss: true})
+		if g.Match(str) || strings.IndexByte(str, 0xff) < 0 && strings.IndexByte(str, 0xfe) < 0 {
+			t.Fatalf("Sample NearMiss of raw bytes: unexpected %q", str)
+		}
+	}
+
+	if str := MustCompile("**").Sample(rng, &SampleOptions{NearMiss: true}); str != "" {
+		t.Errorf("Sample NearMiss of \"**\": expected \"\", got %q", str)
+	}
+}
@@ -0,0 +1,144 @@
+package glob
+
+import (
+	"fmt"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// SegmentType identifies the kind of a compiled Segment.
+type SegmentType byte
+
+const (
+	// LiteralSegment matches a fixed string.
+	LiteralSegment SegmentType = SegmentType(guts.LiteralSegment)
+
+	// RuneMatchSegment matches one rune from a character set, e.g. "[a-z]".
+	RuneMatchSegment SegmentType = SegmentType(guts.RuneMatchSegment)
+
+	// QuestionSegment matches one rune other than '/', i.e. "?".
+	QuestionSegment SegmentType = SegmentType(guts.QuestionSegment)
+
+	// StarSegment matches zero or more runes other than '/', i.e. "*".
+	StarSegment SegmentType = SegmentType(guts.StarSegment)
+
+	// DoubleStarSegment matches zero or more runes of any kind, i.e. "**".
+	DoubleStarSegment SegmentType = SegmentType(guts.DoubleStarSegment)
+
+	// DoubleStarSlashSegment matches zero or more whole directories, i.e. "**/".
+	DoubleStarSlashSegment SegmentType = SegmentType(guts.DoubleStarSlashSegment)
+)
+
+func (x SegmentType) String() string {
+	return guts.SegmentType(x).String()
+}
+
+func (x SegmentType) GoString() string {
+	return guts.SegmentType(x).GoString()
+}
+
+var _ fmt.Stringer = SegmentType(0)
+var _ fmt.GoStringer = SegmentType(0)
+
+// RuneMatcher is the interface implemented by character sets.
+type RuneMatcher interface {
+	// MatchRune returns true iff the rune is a member of the set.
+	MatchRune(rune) bool
+
+	// ForEachRange calls fn for each range of runes in the set, in
+	// ascending order, with both lo and hi inclusive.
+	ForEachRange(fn func(lo, hi rune))
+}
+
+// Segment is a read-only view of one element of a compiled Glob.
+type Segment struct {
+	g *guts.Glob
+	i uint
+}
+
+// NumSegments returns the number of segments in the compiled pattern.  A Glob
+// built by Or, And or Not has none.
+func (g *Glob) NumSegments() uint {
+	return uint(len(g.impl.Segments))
+}
+
+// Segment returns the i'th segment of the compiled pattern.
+func (g *Glob) Segment(i uint) Segment {
+	if i >= g.NumSegments() {
+		panic(fmt.Errorf("segment index %d out of range [0..%d)", i, g.NumSegments()))
+	}
+	return Segment{g: &g.impl, i: i}
+}
+
+// Segments returns all segments of the compiled pattern, in order.
+func (g *Glob) Segments() []Segment {
+	n := g.NumSegments()
+	out := make([]Segment, n)
+	for i := uint(0); i < n; i++ {
+		out[i] = Segment{g: &g.impl, i: i}
+	}
+	return out
+}
+
+func (s Segment) impl() *guts.Segment {
+	return &s.g.Segments[s.i]
+}
+
+// Index returns the position of this segment within its Glob.
+func (s Segment) Index() uint {
+	return s.i
+}
+
+// Type returns the kind of this segment.
+func (s Segment) Type() SegmentType {
+	return SegmentType(s.impl().Type)
+}
+
+// Literal returns the string matched by a LiteralSegment, or "" for other
+// segment types.
+func (s Segment) Literal() string {
+	return s.impl().Literal.String
+}
+
+// Matcher returns the character set matched by a RuneMatchSegment, or nil
+// for other segment types.
+func (s Segment) Matcher() RuneMatcher {
+	if m := s.impl().Matcher; m != nil {
+		return m
+	}
+	return nil
+}
+
+// Class returns the character set matched by a RuneMatchSegment, or nil for
+// other segment types.
+func (s Segment) Class() *Class {
+	if m := s.impl().Matcher; m != nil {
+		return newClass(m)
+	}
+	return nil
+}
+
+func (s Segment) PatternLocation() (uint, uint) {
+	seg := s.impl()
+	return seg.PatternP, seg.PatternQ
+}
+
+func (s Segment) PatternStart() uint {
+	return s.impl().PatternP
+}
+
+func (s Segment) PatternEnd() uint {
+	return s.impl().PatternQ
+}
+
+// Pattern returns the text of the pattern that produced this segment.
+func (s Segment) Pattern() string {
+	seg := s.impl()
+	return s.g.Pattern.Substring(seg.PatternP, seg.PatternQ)
+}
+
+func (s Segment) String() string {
+	return fmt.Sprintf("%v(%q)", s.Type(), s.Pattern())
+}
+
+var _ fmt.Stringer = Segment{}
@@ -0,0 +1,149 @@
+package glob
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// specificity summarizes how narrowly a pattern matches.
+type specificity struct {
+	exact       bool
+	depth       int
+	literal     int
+	doubleStars int
+	stars       int
+	questions   int
+	setSize     uint64
+}
+
+func specificityOf(g *Glob) specificity {
+	switch g.op {
+	case orOp, andOp:
+		// Or is only as specific as its least specific operand, while
+		// And is as specific as its most specific one.
+		out := specificityOf(g.operands[0])
+		for _, operand := range g.operands[1:] {
+			s := specificityOf(operand)
+			c := compareSpecificity(s, out)
+			if (g.op == orOp && c > 0) || (g.op == andOp && c < 0) {
+				out = s
+			}
+		}
+		return out
+	case notOp:
+		// A complement matches almost everything.
+		return specificity{doubleStars: 1}
+	}
+
+	var out specificity
+	anchored := true
+	for i := range g.impl.Segments {
+		seg := &g.impl.Segments[i]
+		switch seg.Type {
+		case guts.LiteralSegment:
+			out.literal += len(seg.Literal.Runes)
+			if anchored {
+				for _, ch := range seg.Literal.Runes {
+					if ch == '/' {
+						out.depth++
+					}
+				}
+			}
+			continue
+		case guts.RuneMatchSegment:
+			n := countRunes(possibleRunes(guts.Ranges(seg.Matcher)))
+			if n == 1 {
+				out.literal++
+				if anchored && seg.Matcher.MatchRune('/') {
+					out.depth++
+				}
+				continue
+			}
+			out.setSize += n
+		case guts.QuestionSegment:
+			out.questions++
+		case guts.StarSegment:
+			out.stars++
+		case guts.DoubleStarSegment, guts.DoubleStarSlashSegment:
+			out.doubleStars++
+		}
+		anchored = false
+	}
+	out.exact = anchored
+	return out
+}
+
+// compareSpecificity returns a negative number if a is more specific than b.
+func compareSpecificity(a, b specificity) int {
+	switch {
+	case a.exact != b.exact:
+		return boolOrder(a.exact)
+	case a.depth != b.depth:
+		return b.depth - a.depth
+	case a.literal != b.literal:
+		return b.literal - a.literal
+	case a.doubleStars != b.doubleStars:
+		return a.doubleStars - b.doubleStars
+	case a.stars != b.stars:
+		return a.stars - b.stars
+	case a.questions != b.questions:
+		return a.questions - b.questions
+	case a.setSize != b.setSize:
+		if a.setSize < b.setSize {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+func boolOrder(first bool) int {
+	if first {
+		return -1
+	}
+	return 1
+}
+
+// CompareSpecificity ranks two patterns by how specific they are, for rule
+// sets where the most specific match wins.  It returns a negative number if
+// a is more specific than b, a positive number if b is more specific than a,
+// and zero only if a and b have the same String.
+//
+// The criteria are applied in order, and the first which differs decides:
+//
+//  1. A pattern which matches exactly one string beats one which doesn't.
+//  2. More '/' in the fixed prefix, before the first wildcard or set, wins.
+//  3. More literal runes in total wins.  A set of one rune counts as literal.
+//  4. Fewer "**" and "**/" wins.
+//  5. Fewer "*" wins.
+//  6. Fewer "?" wins.
+//  7. Fewer runes in total across all character sets wins.
+//  8. The String which sorts first wins, so that the order is total.
+//
+// A Glob built by Or ranks as its least specific operand, And as its most
+// specific operand, and Not as a pattern consisting of "**".
+func CompareSpecificity(a, b *Glob) int {
+	if c := compareSpecificity(specificityOf(a), specificityOf(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a.String(), b.String())
+}
+
+// SortBySpecificity sorts globs from most to least specific, according to
+// CompareSpecificity.
+func SortBySpecificity(globs []*Glob) {
+	keys := make(map[*Glob]specificity, len(globs))
+	for _, g := range globs {
+		keys[g] = specificityOf(g)
+	}
+	sort.SliceStable(globs, func(i, j int) bool {
+		a, b := globs[i], globs[j]
+		if c := compareSpecificity(keys[a], keys[b]); c != 0 {
+			return c < 0
+		}
+		return a.String() < b.String()
+	})
+}
@@ -0,0 +1,48 @@
+package glob
+
+import (
+	"testing"
+)
+
+func TestSortBySpecificity(t *testing.T) {
+	// Most specific first.
+	expected := []string{
+		"src/lib/main.go",
+		"src/lib/*.go",
+		"src/lib/**",
+		"src/main_*.go",
+		"src/[a-c]?.go",
+		"src/[a-z]?.go",
+		"src/??.go",
+		"src/*.go",
+		"src/**/*.go",
+		"src/**",
+		"**/*_test.go",
+		"*.go",
+		"**/*.go",
+		"**",
+	}
+
+	globs := make([]*Glob, len(expected))
+	for i := range expected {
+		// Reverse the order, so that the sort has work to do.
+		globs[len(globs)-1-i] = MustCompile(expected[i])
+	}
+	SortBySpecificity(globs)
+	for i, g := range globs {
+		if g.String() != expected[i] {
+			t.Errorf("[%d]: expected %q, got %q", i, expected[i], g.String())
+		}
+	}
+
+	for i := range expected {
+		for j := range expected {
+			a := MustCompile(expected[i])
+			b := MustCompile(expected[j])
+			c := CompareSpecificity(a, b)
+			if (i < j && c >= 0) || (i > j && c <= 0) || (i == j && c != 0) {
+				t.Errorf("CompareSpecificity(%q, %q): unexpected %d", expected[i], expected[j], c)
+			}
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package glob
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/team-spectre/go-glob/internal/guts"
+)
+
+// TraceEventKind identifies what happened in a TraceEvent.
+type TraceEventKind byte
+
+const (
+	// TryEvent indicates that a segment was tried against the input for
+	// the first time at InputP.
+	TryEvent TraceEventKind = TraceEventKind(guts.TryEvent)
+
+	// MemoHitEvent indicates that the outcome of trying a segment at
+	// InputP was already known, so the work was not repeated.
+	MemoHitEvent TraceEventKind = TraceEventKind(guts.MemoHitEvent)
+
+	// CandidateEvent indicates that a wildcard segment tested whether
+	// consuming the input from InputP to InputQ would let the rest of the
+	// pattern match.  OK holds the answer, and Cached is true if the
+	// answer was already known.
+	CandidateEvent TraceEventKind = TraceEventKind(guts.CandidateEvent)
+
+	// AcceptEvent indicates that a segment matched the input from InputP
+	// to InputQ.
+	AcceptEvent TraceEventKind = TraceEventKind(guts.AcceptEvent)
+
+	// RejectEvent indicates that the match failed; Reason says why.
+	RejectEvent TraceEventKind = TraceEventKind(guts.RejectEvent)
+)
+
+func (x TraceEventKind) String() string {
+	return guts.TraceEventType(x).String()
+}
+
+func (x TraceEventKind) GoString() string {
+	return guts.TraceEventType(x).GoString()
+}
+
+// RejectReason explains a RejectEvent.
+type RejectReason byte
+
+const (
+	// NoReason is the Reason of every event other than a RejectEvent.
+	NoReason RejectReason = RejectReason(guts.NoReason)
+
+	// LengthBoundReason indicates that the remaining input was too short
+	// or too long for the remaining segments to ever match it.
+	LengthBoundReason RejectReason = RejectReason(guts.LengthBoundReason)
+
+	// AffixReason indicates that the input lacks the fixed prefix or
+	// suffix of the pattern, so it was rejected before any segment was
+	// tried.
+	AffixReason RejectReason = RejectReason(guts.AffixReason)
+
+	// LiteralMismatchReason indicates that the input differs from a
+	// LiteralSegment.
+	LiteralMismatchReason RejectReason = RejectReason(guts.LiteralMismatchReason)
+
+	// RuneClassReason indicates that a rune is not in the character set of
+	// a RuneMatchSegment.
+	RuneClassReason RejectReason = RejectReason(guts.RuneClassReason)
+
+	// SeparatorReason indicates that a QuestionSegment met a '/'.
+	SeparatorReason RejectReason = RejectReason(guts.SeparatorReason)
+
+	// EndOfInputReason indicates that the input ended before a segment
+	// was satisfied.
+	EndOfInputReason RejectReason = RejectReason(guts.EndOfInputReason)
+
+	// TrailingInputReason indicates that every segment matched, but input
+	// remained afterward.
+	TrailingInputReason RejectReason = RejectReason(guts.TrailingInputReason)
+
+	// MemoizedReason indicates that the segment had already been rejected
+	// at the same position.
+	MemoizedReason RejectReason = RejectReason(guts.MemoizedReason)
+
+	// BudgetReason indicates that the match was abandoned because it ran
+	// out of steps or its context was done; see Matcher.Err.
+	BudgetReason RejectReason = RejectReason(guts.BudgetReason)
+
+	// DotfileReason indicates that a wildcard, or a literal which does not
+	// begin a path component of the pattern, met a '.' at the start of a
+	// path component of the input.  See Dialect.HideDotfiles.
+	DotfileReason RejectReason = RejectReason(guts.DotfileReason)
+)
+
+func (x RejectReason) String() string {
+	return guts.RejectReason(x).String()
+}
+
+func (x RejectReason) GoString() string {
+	return guts.RejectReason(x).GoString()
+}
+
+// TraceEvent is one step recorded by a tracing Matcher.  PatternP and
+// PatternQ delimit the runes of the segment within the normalized pattern,
+// and InputP and InputQ delimit runes of the normalized input.  Depth counts
+// how many CandidateEvents enclose this one.
+type TraceEvent struct {
+	Kind     TraceEventKind
+	Reason   RejectReason
+	Cached   bool
+	OK       bool
+	Depth    uint
+	Segment  uint
+	PatternP uint
+	PatternQ uint
+	InputP   uint
+	InputQ   uint
+}
+
+// TraceStats summarizes a Trace.
+type TraceStats struct {
+	Tries            uint
+	MemoHits         uint
+	Candidates       uint
+	CachedCandidates uint
+	Accepts          uint
+	Rejects          uint
+	MaxDepth         uint
+}
+
+// Trace is a record of the work done by a Matcher, for explaining why a
+// pattern does or does not match.
+type Trace struct {
+	pattern guts.ExplodedString
+	input   guts.ExplodedString
+	ok      bool
+	events  []TraceEvent
+}
+
+// EnableTrace makes the Matcher record a Trace of its work.  It must be
+// called before the first call to HasNext.  Tracing is slow, and is meant
+// for debugging only.
+//
+// For a Glob built by Or, And or Not, each operand is traced separately,
+// and Trace returns the trace of the operand which supplies the captures.
+func (m *Matcher) EnableTrace() {
+	if m.x != nil {
+		for _, sub := range m.x.subs {
+			sub.EnableTrace()
+		}
+		return
+	}
+	if m.impl.Trace == nil {
+		m.impl.Trace = new(guts.Trace)
+		// Start over, so that any up-front rejection is recorded too.
+		m.impl.Reset(m.g, m.impl.Input.String)
+	}
+}
+
+// Trace returns a snapshot of the work recorded since the Matcher was
+// created or last Reset, or nil if EnableTrace was not called.
+func (m *Matcher) Trace() *Trace {
+	if m.x != nil {
+		if m.x.pick == nil {
+			return nil
+		}
+		return m.x.pick.Trace()
+	}
+	t := m.impl.Trace
+	if t == nil {
+		return nil
+	}
+
+	events := make([]TraceEvent, len(t.Events))
+	for i, e := range t.Events {
+		events[i] = TraceEvent{
+			Kind:     TraceEventKind(e.Type),
+			Reason:   RejectReason(e.Reason),
+			Cached:   e.Cached,
+			OK:       e.OK,
+			Depth:    e.Depth,
+			Segment:  e.SegmentI,
+			PatternP: e.PatternP,
+			PatternQ: e.PatternQ,
+			InputP:   e.InputP,
+			InputQ:   e.InputQ,
+		}
+	}
+
+	// The Matcher reuses its input buffer on Reset, so take a copy.
+	input := m.impl.Input
+	input.Runes = append([]rune(nil), input.Runes...)
+	input.Map = append([]uint(nil), input.Map...)
+
+	return &Trace{
+		pattern: m.g.Pattern,
+		input:   input,
+		ok:      m.impl.OK(),
+		events:  events,
+	}
+}
+
+// Events returns the recorded events, in order.
+func (t *Trace) Events() []TraceEvent {
+	return t.events
+}
+
+// Stats counts the recorded events by kind.
+func (t *Trace) Stats() TraceStats {
+	var stats TraceStats
+	for _, e := range t.events {
+		switch e.Kind {
+		case TryEvent:
+			stats.Tries++
+		case MemoHitEvent:
+			stats.MemoHits++
+		case CandidateEvent:
+			stats.Candidates++
+			if e.Cached {
+				stats.CachedCandidates++
+			}
+		case AcceptEvent:
+			stats.Accepts++
+		case RejectEvent:
+			stats.Rejects++
+		}
+		if e.Depth > stats.MaxDepth {
+			stats.MaxDepth = e.Depth
+		}
+	}
+	return stats
+}
+
+// WriteTo pretty-prints the trace, one event per line, indented by depth.
+func (t *Trace) WriteTo(w io.Writer) (int64, error) {
+	var buf strings.Builder
+	for _, e := range t.events {
+		t.appendEvent(&buf, e)
+	}
+	stats := t.Stats()
+	fmt.Fprintf(&buf, "%s after %d tries, %d memo hits, %d candidates (%d cached)\n",
+		t.outcome(), stats.Tries, stats.MemoHits, stats.Candidates, stats.CachedCandidates)
+	n, err := io.WriteString(w, buf.String())
+	return int64(n), err
+}
+
+func (t *Trace) String() string {
+	var buf strings.Builder
+	t.WriteTo(&buf)
+	return buf.String()
+}
+
+func (t *Trace) outcome() string {
+	if t.ok {
+		return "matched"
+	}
+	return "rejected"
+}
+
+func (t *Trace) appendEvent(buf *strings.Builder, e TraceEvent) {
+	for i := uint(0); i < e.Depth; i++ {
+		buf.WriteString("  ")
+	}
+	fmt.Fprintf(buf, "%-9s ", strings.TrimSuffix(strings.ToLower(e.Kind.String()), "event"))
+	if e.PatternP < e.PatternQ {
+		fmt.Fprintf(buf, "#%d %q ", e.Segment, t.pattern.Substring(e.PatternP, e.PatternQ))
+	} else if e.Segment == 0 {
+		buf.WriteString("pattern ")
+	} else {
+		buf.WriteString("end ")
+	}
+	if e.InputP == e.InputQ {
+		fmt.Fprintf(buf, "at %d", e.InputP)
+	} else {
+		fmt.Fprintf(buf, "[%d:%d] %q", e.InputP, e.InputQ, t.input.Substring(e.InputP, e.InputQ))
+	}
+	switch e.Kind {
+	case MemoHitEvent, CandidateEvent:
+		if e.Cached {
+			buf.WriteString(" (cached)")
+		}
+		if e.OK {
+			buf.WriteString(" -> ok")
+		} else {
+			buf.WriteString(" -> fail")
+		}
+	case RejectEvent:
+		buf.WriteString(": ")
+		buf.WriteString(guts.RejectReason(e.Reason).Message())
+	}
+	buf.WriteByte('\n')
+}
+
+var _ fmt.Stringer = TraceEventKind(0)
+var _ fmt.GoStringer = TraceEventKind(0)
+var _ fmt.Stringer = RejectReason(0)
+var _ fmt.GoStringer = RejectReason(0)
+var _ fmt.Stringer = (*Trace)(nil)
+var _ io.WriterTo = (*Trace)(nil)